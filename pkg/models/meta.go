@@ -3,4 +3,10 @@ package models
 // TwinMakerCustomMeta is the standard metadata
 type TwinMakerCustomMeta struct {
 	NextToken string `json:"nextToken,omitempty"`
+
+	// Number of duplicate summaries dropped while merging pages
+	DuplicatesDropped int `json:"duplicatesDropped,omitempty"`
+
+	// Number of times the service restarted pagination with a token that was already followed
+	PaginationRestarts int `json:"paginationRestarts,omitempty"`
 }
@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/iottwinmaker"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
@@ -46,20 +47,60 @@ func (f *TwinMakerPropertyFilter) ToTwinMakerFilter() *iottwinmaker.PropertyFilt
 	return filter
 }
 
+// TwinMakerTimeWindow is an inclusive time interval
+type TwinMakerTimeWindow struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"` // zero means open ended
+}
+
+// TwinMakerSuppression masks ACTIVE alarm states during maintenance
+type TwinMakerSuppression struct {
+	Enabled bool                  `json:"enabled,omitempty"`
+	Windows []TwinMakerTimeWindow `json:"windows,omitempty"`
+
+	// Optional maintenance component consulted at query time, any interval
+	// where the (boolean) property is true is treated as a maintenance window
+	EntityId      string `json:"entityId,omitempty"`
+	ComponentName string `json:"componentName,omitempty"`
+	PropertyName  string `json:"propertyName,omitempty"`
+}
+
+type TwinMakerQualityMode = string
+
+const (
+	QualityModeFlag   TwinMakerQualityMode = "FLAG"   // add a quality field next to the values
+	QualityModeFilter TwinMakerQualityMode = "FILTER" // drop samples that are not GOOD
+)
+
+// TwinMakerQuality links a value property to a parallel data quality property
+type TwinMakerQuality struct {
+	Property        string               `json:"property"`
+	QualityProperty string               `json:"qualityProperty"`
+	Mode            TwinMakerQualityMode `json:"mode,omitempty"`
+	ToleranceMs     int64                `json:"toleranceMs,omitempty"` // max timestamp distance when aligning
+}
+
+func (q *TwinMakerQuality) Tolerance() time.Duration {
+	return time.Duration(q.ToleranceMs) * time.Millisecond
+}
+
 // TwinMakerQuery model
 type TwinMakerQuery struct {
-	WorkspaceId     string               `json:"workspaceId,omitempty"`
-	EntityId        string               `json:"entityId,omitempty"`
-	Properties      []*string            `json:"properties,omitempty"`
-	NextToken       string               `json:"nextToken,omitempty"`
-	ComponentName   string               `json:"componentName,omitempty"`
-	ComponentTypeId string               `json:"componentTypeId,omitempty"`
+	WorkspaceId     string                    `json:"workspaceId,omitempty"`
+	EntityId        string                    `json:"entityId,omitempty"`
+	Properties      []*string                 `json:"properties,omitempty"`
+	NextToken       string                    `json:"nextToken,omitempty"`
+	MaxResults      int64                     `json:"maxResults,omitempty"`
+	ComponentName   string                    `json:"componentName,omitempty"`
+	ComponentTypeId string                    `json:"componentTypeId,omitempty"`
 	Filter          []TwinMakerPropertyFilter `json:"filter,omitempty"`
 	Order           TwinMakerResultOrder      `json:"order,omitempty"`
+	Suppression     *TwinMakerSuppression     `json:"suppression,omitempty"`
+	Quality         *TwinMakerQuality         `json:"quality,omitempty"`
 
 	// Direct from the gRPC interfaces
-	QueryType TwinMakerQueryType     `json:"-"`
-	TimeRange backend.TimeRange `json:"-"`
+	QueryType TwinMakerQueryType `json:"-"`
+	TimeRange backend.TimeRange  `json:"-"`
 }
 
 func (q *TwinMakerQuery) CacheKey(pfix string) string {
@@ -3,23 +3,58 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
+const (
+	DefaultSessionDuration = time.Hour
+	DefaultCacheTTL        = 30 * time.Minute
+
+	// limits enforced by STS for the frontend credentials
+	minSessionDuration = 15 * time.Minute
+	maxSessionDuration = 12 * time.Hour
+)
+
 type TwinMakerDataSourceSetting struct {
 	awsds.AWSDatasourceSettings
 	WorkspaceID string `json:"workspaceId"`
+
+	// Version of the persisted json shape, see MigrateSettings
+	SettingsVersion int `json:"settingsVersion"`
+
+	SessionDuration string `json:"sessionDuration,omitempty"` // duration of the frontend session token, ie "1h"
+	CacheTTL        string `json:"cacheTTL,omitempty"`        // how long query and resource results are cached, ie "30m"
+	STSEndpoint     string `json:"stsEndpoint,omitempty"`     // override the STS endpoint (default is the standard one)
+	AllowWrites     bool   `json:"allowWrites,omitempty"`     // reserved for write operations, off by default
 }
 
 func (s *TwinMakerDataSourceSetting) Load(config backend.DataSourceInstanceSettings) error {
+	raw := map[string]interface{}{}
 	if config.JSONData != nil && len(config.JSONData) > 1 {
-		if err := json.Unmarshal(config.JSONData, s); err != nil {
+		if err := json.Unmarshal(config.JSONData, &raw); err != nil {
 			return fmt.Errorf("could not unmarshal DatasourceSettings json: %w", err)
 		}
 	}
 
+	// the migrated shape is not saved back, so older settings are migrated in memory on each load
+	migrations := MigrateSettings(raw)
+	if len(migrations) > 0 {
+		backend.Logger.Debug("Migrated datasource settings", "uid", config.UID, "migrations", migrations)
+	}
+
+	// raw was read from json so this is only a reshape
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("could not marshal migrated DatasourceSettings: %w", err)
+	}
+	if err := json.Unmarshal(migrated, s); err != nil {
+		return fmt.Errorf("could not unmarshal DatasourceSettings json: %w", err)
+	}
+
+	// not a migration, the config editor still saves "default" with a separate defaultRegion
 	if s.Region == "default" || s.Region == "" {
 		s.Region = s.DefaultRegion
 	}
@@ -33,10 +68,45 @@ func (s *TwinMakerDataSourceSetting) Load(config backend.DataSourceInstanceSetti
 }
 
 func (s *TwinMakerDataSourceSetting) Validate() error {
-	// OK
+	if s.SessionDuration != "" {
+		d, err := time.ParseDuration(s.SessionDuration)
+		if err != nil {
+			return fmt.Errorf("invalid session duration: %w", err)
+		}
+		if d < minSessionDuration || d > maxSessionDuration {
+			return fmt.Errorf("session duration must be between %v and %v", minSessionDuration, maxSessionDuration)
+		}
+	}
+	if s.CacheTTL != "" {
+		d, err := time.ParseDuration(s.CacheTTL)
+		if err != nil {
+			return fmt.Errorf("invalid cache TTL: %w", err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("cache TTL must be positive")
+		}
+	}
 	return nil
 }
 
+// GetSessionDuration returns the configured session duration or the default
+func (s *TwinMakerDataSourceSetting) GetSessionDuration() time.Duration {
+	return parseDurationOr(s.SessionDuration, DefaultSessionDuration)
+}
+
+// GetCacheTTL returns the configured cache TTL or the default
+func (s *TwinMakerDataSourceSetting) GetCacheTTL() time.Duration {
+	return parseDurationOr(s.CacheTTL, DefaultCacheTTL)
+}
+
+func parseDurationOr(v string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
 func (s *TwinMakerDataSourceSetting) ToAWSDatasourceSettings() awsds.AWSDatasourceSettings {
 	cfg := awsds.AWSDatasourceSettings{
 		Profile:       s.Profile,
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"encoding/json"
+)
+
+// CurrentSettingsVersion is the version of the TwinMakerDataSourceSetting json shape
+const CurrentSettingsVersion = 1
+
+type settingsMigration struct {
+	version int // the version this migration upgrades to
+	name    string
+	apply   func(raw map[string]interface{}) bool // true when the settings changed
+}
+
+// Ordered by version.  Migrations only add missing values so older provisioning
+// files (and older plugin versions reading the same settings) keep working.  Legacy
+// keys and values (assumeRoleArn, authType "arn") are already read by awsds.
+var settingsMigrations = []settingsMigration{
+	{
+		version: 1,
+		name:    "session duration default",
+		apply: func(raw map[string]interface{}) bool {
+			return setDefault(raw, "sessionDuration", DefaultSessionDuration.String())
+		},
+	},
+	{
+		version: 1,
+		name:    "cache TTL default",
+		apply: func(raw map[string]interface{}) bool {
+			return setDefault(raw, "cacheTTL", DefaultCacheTTL.String())
+		},
+	},
+	{
+		version: 1,
+		name:    "allowWrites default",
+		apply: func(raw map[string]interface{}) bool {
+			if _, ok := raw["allowWrites"]; !ok {
+				raw["allowWrites"] = false
+				return true
+			}
+			return false
+		},
+	},
+}
+
+func setDefault(raw map[string]interface{}, key string, value string) bool {
+	if v, ok := raw[key].(string); !ok || v == "" {
+		raw[key] = value
+		return true
+	}
+	return false
+}
+
+func settingsVersion(raw map[string]interface{}) int {
+	switch v := raw["settingsVersion"].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case json.Number:
+		i, _ := v.Int64()
+		return int(i)
+	}
+	return 0 // the original shape had no version
+}
+
+// MigrateSettings upgrades the json settings in place to the current
+// version and returns the names of the migrations that changed something.  Settings that are
+// already current (or newer) are not modified, so it is safe to run repeatedly.
+func MigrateSettings(raw map[string]interface{}) []string {
+	version := settingsVersion(raw)
+	if version >= CurrentSettingsVersion {
+		return nil
+	}
+
+	ran := []string{}
+	for _, m := range settingsMigrations {
+		if m.version > version && m.apply(raw) {
+			ran = append(ran, m.name)
+		}
+	}
+	raw["settingsVersion"] = CurrentSettingsVersion
+	return ran
+}
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func loadSettingsFixture(t *testing.T, name string) []byte {
+	bs, err := ioutil.ReadFile("./testdata/" + name + ".json")
+	require.NoError(t, err)
+	return bs
+}
+
+func TestLoadSettings(t *testing.T) {
+	tests := []struct {
+		fixture    string
+		migrations int
+		expected   TwinMakerDataSourceSetting
+	}{
+		{
+			fixture:    "settings-v0",
+			migrations: 3,
+			expected: TwinMakerDataSourceSetting{
+				AWSDatasourceSettings: awsds.AWSDatasourceSettings{
+					AuthType: awsds.AuthTypeKeys,
+					Region:   "us-west-2",
+				},
+				WorkspaceID:     "CookieFactory",
+				SettingsVersion: CurrentSettingsVersion,
+				SessionDuration: "1h0m0s",
+				CacheTTL:        "30m0s",
+			},
+		},
+		{
+			fixture:    "settings-v0-default-region",
+			migrations: 3,
+			expected: TwinMakerDataSourceSetting{
+				AWSDatasourceSettings: awsds.AWSDatasourceSettings{
+					AuthType:      awsds.AuthTypeDefault,
+					Region:        "eu-west-1",
+					DefaultRegion: "eu-west-1",
+					AssumeRoleARN: "arn:aws:iam::123456789012:role/TwinMakerDashboardRole",
+				},
+				WorkspaceID:     "CookieFactory",
+				SettingsVersion: CurrentSettingsVersion,
+				SessionDuration: "1h0m0s",
+				CacheTTL:        "30m0s",
+			},
+		},
+		{
+			fixture:    "settings-v0-legacy-auth",
+			migrations: 3,
+			expected: TwinMakerDataSourceSetting{
+				AWSDatasourceSettings: awsds.AWSDatasourceSettings{
+					AuthType:      awsds.AuthTypeDefault,
+					Region:        "ap-southeast-1",
+					DefaultRegion: "ap-southeast-1",
+					AssumeRoleARN: "arn:aws:iam::123456789012:role/TwinMakerDashboardRole",
+				},
+				WorkspaceID:     "CookieFactory",
+				SettingsVersion: CurrentSettingsVersion,
+				SessionDuration: "1h0m0s",
+				CacheTTL:        "30m0s",
+			},
+		},
+		{
+			fixture:    "settings-v1",
+			migrations: 0,
+			expected: TwinMakerDataSourceSetting{
+				AWSDatasourceSettings: awsds.AWSDatasourceSettings{
+					AuthType: awsds.AuthTypeKeys,
+					Region:   "us-east-1",
+				},
+				WorkspaceID:     "CookieFactory",
+				SettingsVersion: CurrentSettingsVersion,
+				SessionDuration: "2h",
+				CacheTTL:        "5m",
+				STSEndpoint:     "https://sts.us-east-1.amazonaws.com",
+				AllowWrites:     true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			bs := loadSettingsFixture(t, test.fixture)
+
+			raw := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal(bs, &raw))
+			require.Len(t, MigrateSettings(raw), test.migrations)
+
+			settings := TwinMakerDataSourceSetting{}
+			err := settings.Load(backend.DataSourceInstanceSettings{JSONData: bs})
+			require.NoError(t, err)
+			require.NoError(t, settings.Validate())
+			require.Equal(t, test.expected, settings)
+		})
+	}
+}
+
+func TestMigrateSettingsIsIdempotent(t *testing.T) {
+	for _, fixture := range []string{"settings-v0", "settings-v0-default-region", "settings-v0-legacy-auth", "settings-v1"} {
+		t.Run(fixture, func(t *testing.T) {
+			raw := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal(loadSettingsFixture(t, fixture), &raw))
+			MigrateSettings(raw)
+
+			once, err := json.Marshal(raw)
+			require.NoError(t, err)
+			require.Empty(t, MigrateSettings(raw))
+			twice, err := json.Marshal(raw)
+			require.NoError(t, err)
+			require.JSONEq(t, string(once), string(twice))
+		})
+	}
+}
+
+func TestMigrateSettingsKeepsOldFields(t *testing.T) {
+	raw := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(loadSettingsFixture(t, "settings-v0-legacy-auth"), &raw))
+	require.Equal(t, []string{
+		"session duration default",
+		"cache TTL default",
+		"allowWrites default",
+	}, MigrateSettings(raw))
+
+	// legacy keys and values are left for awsds and the frontend to read
+	require.Equal(t, "arn", raw["authType"])
+	require.Equal(t, "arn:aws:iam::123456789012:role/TwinMakerDashboardRole", raw["assumeRoleArn"])
+	require.NotContains(t, raw, "assumeRoleARN")
+	require.Equal(t, "ap-southeast-1", raw["defaultRegion"])
+	require.NotContains(t, raw, "region")
+}
+
+func TestSettingsDurations(t *testing.T) {
+	settings := TwinMakerDataSourceSetting{}
+	require.NoError(t, settings.Validate())
+	require.Equal(t, DefaultSessionDuration, settings.GetSessionDuration())
+	require.Equal(t, DefaultCacheTTL, settings.GetCacheTTL())
+
+	settings.SessionDuration = "90m"
+	settings.CacheTTL = "10s"
+	require.NoError(t, settings.Validate())
+	require.Equal(t, 90*time.Minute, settings.GetSessionDuration())
+	require.Equal(t, 10*time.Second, settings.GetCacheTTL())
+
+	settings.SessionDuration = "1m"
+	require.Error(t, settings.Validate())
+	settings.SessionDuration = "1h"
+	settings.CacheTTL = "soon"
+	require.Error(t, settings.Validate())
+}
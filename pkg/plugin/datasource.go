@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/gorilla/mux"
@@ -36,6 +35,10 @@ type TwinMakerDatasource struct {
 	client   twinmaker.TwinMakerClient // only used for healthcheck
 	handler  twinmaker.TwinMakerHandler
 	res      twinmaker.TwinMakerResources
+
+	// diagnostics for the support bundle
+	caches []twinmaker.CacheStatsProvider
+	errors *recentErrors
 }
 
 // Make sure TwinMakerDatasource implements required interfaces.
@@ -64,7 +67,7 @@ func NewTwinMakerDatasource(settings models.TwinMakerDataSourceSetting) *TwinMak
 }
 
 func newTwinMakerDatasource(settings models.TwinMakerDataSourceSetting, c twinmaker.TwinMakerClient) *TwinMakerDatasource {
-	ttl := 30 * time.Minute
+	ttl := settings.GetCacheTTL()
 	cachingClient := twinmaker.NewCachingClient(c, ttl)
 
 	r := mux.NewRouter()
@@ -78,6 +81,12 @@ func newTwinMakerDatasource(settings models.TwinMakerDataSourceSetting, c twinma
 		res: twinmaker.NewCachingResource(
 			twinmaker.NewTwinMakerResource(c, settings.WorkspaceID),
 			ttl),
+		errors: newRecentErrors(recentErrorsSize),
+	}
+	for _, v := range []interface{}{cachingClient, ds.res} {
+		if p, ok := v.(twinmaker.CacheStatsProvider); ok {
+			ds.caches = append(ds.caches, p)
+		}
 	}
 	r.HandleFunc("/token", ds.HandleGetToken)
 
@@ -87,6 +96,7 @@ func newTwinMakerDatasource(settings models.TwinMakerDataSourceSetting, c twinma
 	r.HandleFunc("/list/scenes", ds.HandleListScenes)
 	r.HandleFunc("/list/options", ds.HandleListOptions)
 	r.HandleFunc("/list/entity", ds.HandleListEntityOptions)
+	r.HandleFunc("/admin/support-bundle", ds.HandleSupportBundle)
 	return ds
 }
 
@@ -111,12 +121,23 @@ func (ds *TwinMakerDatasource) QueryData(ctx context.Context, req *backend.Query
 		} else {
 			response.Responses[q.RefID] = ds.DoQuery(ctx, query)
 		}
+		ds.errors.add("query "+q.QueryType, response.Responses[q.RefID].Error)
 	}
 
 	return response, nil
 }
 
-func (ds *TwinMakerDatasource) CheckHealth(ctx context.Context, _ *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+func (ds *TwinMakerDatasource) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	res, err := ds.checkHealth(ctx, req)
+	if err != nil {
+		ds.errors.add("health", err)
+	} else if res.Status != backend.HealthStatusOk {
+		ds.errors.addMessage("health", res.Message)
+	}
+	return res, err
+}
+
+func (ds *TwinMakerDatasource) checkHealth(ctx context.Context, _ *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
 	if ds.settings.WorkspaceID == "" {
 		return &backend.CheckHealthResult{
 			Status:  backend.HealthStatusError,
@@ -124,7 +145,7 @@ func (ds *TwinMakerDatasource) CheckHealth(ctx context.Context, _ *backend.Check
 		}, nil
 	}
 	
-	_, err := ds.handler.GetSessionToken(ctx, time.Second*3600, ds.settings.WorkspaceID)
+	_, err := ds.handler.GetSessionToken(ctx, ds.settings.GetSessionDuration(), ds.settings.WorkspaceID)
 	if err != nil {
 		awsErr, ok := err.(awserr.Error)
 		if ok {
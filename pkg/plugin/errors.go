@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"sync"
+	"time"
+)
+
+const recentErrorsSize = 25
+
+type recordedError struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Message string    `json:"message"`
+}
+
+// recentErrors is a fixed size ring buffer of the latest query, health and resource
+// errors, used for diagnostics
+type recentErrors struct {
+	mu      sync.Mutex
+	entries []recordedError
+	next    int
+}
+
+func newRecentErrors(size int) *recentErrors {
+	return &recentErrors{
+		entries: make([]recordedError, 0, size),
+	}
+}
+
+func (r *recentErrors) add(source string, err error) {
+	if err == nil {
+		return
+	}
+	r.addMessage(source, err.Error())
+}
+
+func (r *recentErrors) addMessage(source string, msg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e := recordedError{Time: time.Now(), Source: source, Message: msg}
+	if len(r.entries) < cap(r.entries) {
+		r.entries = append(r.entries, e)
+		return
+	}
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+}
+
+// list returns the errors, oldest first
+func (r *recentErrors) list() []recordedError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]recordedError, 0, len(r.entries))
+	out = append(out, r.entries[r.next:]...)
+	out = append(out, r.entries[:r.next]...)
+	return out
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"time"
 )
 
 func writeJsonResponse(w http.ResponseWriter, rsp interface{}, err error) {
@@ -18,9 +17,15 @@ func writeJsonResponse(w http.ResponseWriter, rsp interface{}, err error) {
 	}
 }
 
+// writeResourceResponse records failed resource calls with the recent errors
+func (ds *TwinMakerDatasource) writeResourceResponse(w http.ResponseWriter, r *http.Request, rsp interface{}, err error) {
+	ds.errors.add("resource "+r.URL.Path, err)
+	writeJsonResponse(w, rsp, err)
+}
+
 func (ds *TwinMakerDatasource) HandleGetToken(w http.ResponseWriter, r *http.Request) {
-	token, err := ds.handler.GetSessionToken(r.Context(), time.Second*3600, ds.settings.WorkspaceID)
-	writeJsonResponse(w, token, err)
+	token, err := ds.handler.GetSessionToken(r.Context(), ds.settings.GetSessionDuration(), ds.settings.WorkspaceID)
+	ds.writeResourceResponse(w, r, token, err)
 }
 
 func (ds *TwinMakerDatasource) HandleGetEntity(w http.ResponseWriter, r *http.Request) {
@@ -34,22 +39,22 @@ func (ds *TwinMakerDatasource) HandleGetEntity(w http.ResponseWriter, r *http.Re
 	}
 
 	rsp, err := ds.res.GetEntity(r.Context(), entityId)
-	writeJsonResponse(w, rsp, err)
+	ds.writeResourceResponse(w, r, rsp, err)
 }
 
 func (ds *TwinMakerDatasource) HandleListWorkspaces(w http.ResponseWriter, r *http.Request) {
 	rsp, err := ds.res.ListWorkspaces(r.Context())
-	writeJsonResponse(w, rsp, err)
+	ds.writeResourceResponse(w, r, rsp, err)
 }
 
 func (ds *TwinMakerDatasource) HandleListScenes(w http.ResponseWriter, r *http.Request) {
 	rsp, err := ds.res.ListScenes(r.Context())
-	writeJsonResponse(w, rsp, err)
+	ds.writeResourceResponse(w, r, rsp, err)
 }
 
 func (ds *TwinMakerDatasource) HandleListOptions(w http.ResponseWriter, r *http.Request) {
 	rsp, err := ds.res.ListOptions(r.Context())
-	writeJsonResponse(w, rsp, err)
+	ds.writeResourceResponse(w, r, rsp, err)
 }
 
 func (ds *TwinMakerDatasource) HandleListEntityOptions(w http.ResponseWriter, r *http.Request) {
@@ -62,5 +67,5 @@ func (ds *TwinMakerDatasource) HandleListEntityOptions(w http.ResponseWriter, r
 	}
 
 	rsp, err := ds.res.ListEntity(r.Context(), entityId)
-	writeJsonResponse(w, rsp, err)
+	ds.writeResourceResponse(w, r, rsp, err)
 }
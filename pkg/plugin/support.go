@@ -0,0 +1,181 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/grafana/grafana-iot-twinmaker-app/pkg/plugin/twinmaker"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	"github.com/grafana/grafana-plugin-sdk-go/build"
+)
+
+const redacted = "[redacted]"
+
+// runs of digits, the ones exactly 12 long are AWS account ids, ie in role ARNs or
+// AWS error messages.  Word boundaries would miss ids next to letters or "_"
+var digitRuns = regexp.MustCompile(`\d+`)
+
+type supportBundle struct {
+	Generated    time.Time               `json:"generated"`
+	Versions     supportVersions         `json:"versions"`
+	Health       supportHealth           `json:"health"`
+	Settings     supportSettings         `json:"settings"`
+	Caches       []twinmaker.CacheStats  `json:"caches"`
+	RecentErrors []recordedError         `json:"recentErrors"`
+	Retry        twinmaker.RetrySettings `json:"retry"`
+}
+
+type supportVersions struct {
+	Plugin    string `json:"plugin"`
+	Hash      string `json:"hash,omitempty"`
+	Grafana   string `json:"grafana,omitempty"`
+	PluginSDK string `json:"pluginSdk"`
+	AWSSDK    string `json:"awsSdk"`
+	Go        string `json:"go"`
+}
+
+type supportHealth struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// supportSettings is the datasource configuration without secrets or account ids
+type supportSettings struct {
+	SettingsVersion int    `json:"settingsVersion"`
+	AuthType        string `json:"authType"`
+	Profile         string `json:"profile,omitempty"`
+	Region          string `json:"region,omitempty"`
+	DefaultRegion   string `json:"defaultRegion,omitempty"`
+	AssumeRoleARN   string `json:"assumeRoleARN,omitempty"`
+	ExternalID      string `json:"externalId,omitempty"`
+	Endpoint        string `json:"endpoint,omitempty"`
+	STSEndpoint     string `json:"stsEndpoint,omitempty"`
+	WorkspaceID     string `json:"workspaceId,omitempty"`
+	SessionDuration string `json:"sessionDuration"`
+	CacheTTL        string `json:"cacheTTL"`
+	AllowWrites     bool   `json:"allowWrites"`
+	AccessKey       string `json:"accessKey,omitempty"`
+	SecretKey       string `json:"secretKey,omitempty"`
+	SessionToken    string `json:"sessionToken,omitempty"`
+}
+
+// supportRedactor hides the configured secrets and any account ids in free text
+type supportRedactor struct {
+	secrets []string
+}
+
+func (r *supportRedactor) redact(s string) string {
+	for _, secret := range r.secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	return digitRuns.ReplaceAllStringFunc(s, func(digits string) string {
+		if len(digits) == 12 {
+			return strings.Repeat("*", 12)
+		}
+		return digits
+	})
+}
+
+func redactSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func dependencyVersion(path string) string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == path {
+				return dep.Version
+			}
+		}
+	}
+	return "unknown"
+}
+
+func (ds *TwinMakerDatasource) getSupportBundle(ctx context.Context) supportBundle {
+	s := ds.settings
+	r := &supportRedactor{
+		secrets: []string{s.AccessKey, s.SecretKey, s.SessionToken, s.ExternalID},
+	}
+
+	buildInfo, err := build.GetBuildInfo()
+	if err != nil {
+		buildInfo.Version = "dev"
+	}
+
+	bundle := supportBundle{
+		Generated: time.Now().UTC(),
+		Versions: supportVersions{
+			Plugin:    buildInfo.Version,
+			Hash:      buildInfo.Hash,
+			Grafana:   os.Getenv("GF_VERSION"),
+			PluginSDK: dependencyVersion("github.com/grafana/grafana-plugin-sdk-go"),
+			AWSSDK:    aws.SDKVersion,
+			Go:        runtime.Version(),
+		},
+		Settings: supportSettings{
+			SettingsVersion: s.SettingsVersion,
+			AuthType:        s.AuthType.String(),
+			Profile:         r.redact(s.Profile),
+			Region:          s.Region,
+			DefaultRegion:   s.DefaultRegion,
+			AssumeRoleARN:   r.redact(s.AssumeRoleARN),
+			ExternalID:      redactSecret(s.ExternalID),
+			Endpoint:        r.redact(s.Endpoint),
+			STSEndpoint:     r.redact(s.STSEndpoint),
+			WorkspaceID:     r.redact(s.WorkspaceID),
+			SessionDuration: s.GetSessionDuration().String(),
+			CacheTTL:        s.GetCacheTTL().String(),
+			AllowWrites:     s.AllowWrites,
+			AccessKey:       redactSecret(s.AccessKey),
+			SecretKey:       redactSecret(s.SecretKey),
+			SessionToken:    redactSecret(s.SessionToken),
+		},
+		Caches: make([]twinmaker.CacheStats, 0, len(ds.caches)),
+		Retry:  twinmaker.EffectiveRetrySettings(),
+	}
+
+	// not recorded in the recent errors, the bundle should not change the diagnostics it reports
+	health, err := ds.checkHealth(ctx, &backend.CheckHealthRequest{})
+	if err != nil {
+		bundle.Health = supportHealth{Status: backend.HealthStatusError.String(), Message: r.redact(err.Error())}
+	} else {
+		bundle.Health = supportHealth{Status: health.Status.String(), Message: r.redact(health.Message)}
+	}
+
+	for _, c := range ds.caches {
+		bundle.Caches = append(bundle.Caches, c.CacheStats())
+	}
+
+	// copied after the health check so its failure is included
+	bundle.RecentErrors = ds.errors.list()
+	for i, e := range bundle.RecentErrors {
+		bundle.RecentErrors[i].Message = r.redact(e.Message)
+	}
+	return bundle
+}
+
+// HandleSupportBundle returns the diagnostics to attach to bug reports (admin only)
+func (ds *TwinMakerDatasource) HandleSupportBundle(w http.ResponseWriter, r *http.Request) {
+	user := httpadapter.UserFromContext(r.Context())
+	if user == nil || user.Role != "Admin" {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message": "support bundle requires the Admin role"}`))
+		return
+	}
+
+	writeJsonResponse(w, ds.getSupportBundle(r.Context()), nil)
+}
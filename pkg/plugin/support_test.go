@@ -0,0 +1,146 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
+	"github.com/grafana/grafana-iot-twinmaker-app/pkg/models"
+	"github.com/grafana/grafana-iot-twinmaker-app/pkg/plugin/twinmaker"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+type captureSender struct {
+	rsp *backend.CallResourceResponse
+}
+
+func (s *captureSender) Send(rsp *backend.CallResourceResponse) error {
+	s.rsp = rsp
+	return nil
+}
+
+func callResource(t *testing.T, ds *TwinMakerDatasource, path string, user *backend.User) *backend.CallResourceResponse {
+	sender := &captureSender{}
+	err := ds.CallResource(context.Background(), &backend.CallResourceRequest{
+		Path:          path,
+		Method:        http.MethodGet,
+		PluginContext: backend.PluginContext{User: user},
+	}, sender)
+	require.NoError(t, err)
+	require.NotNil(t, sender.rsp)
+	return sender.rsp
+}
+
+func TestSupportBundle(t *testing.T) {
+	// the mock has no saved responses here, so the health check fails
+	client, err := twinmaker.NewTwinMakerMockClient("missing")
+	require.NoError(t, err)
+
+	secrets := []string{"ACCESS-KEY-MARKER", "SECRET-KEY-MARKER", "SESSION-TOKEN-MARKER", "EXTERNAL-ID-MARKER"}
+	ds := newTwinMakerDatasource(models.TwinMakerDataSourceSetting{
+		AWSDatasourceSettings: awsds.AWSDatasourceSettings{
+			AuthType:      awsds.AuthTypeKeys,
+			Region:        "us-east-1",
+			AssumeRoleARN: "arn:aws:iam::123456789012:role/TwinMakerDashboardRole",
+			AccessKey:     secrets[0],
+			SecretKey:     secrets[1],
+			SessionToken:  secrets[2],
+			ExternalID:    secrets[3],
+		},
+		WorkspaceID:     "CookieFactory",
+		SettingsVersion: models.CurrentSettingsVersion,
+		CacheTTL:        "5m",
+	}, client)
+
+	// errors from AWS can echo account ids and (badly behaved) credentials
+	ds.errors.addMessage("query GetAlarms", fmt.Sprintf("AccessDenied: arn:aws:sts::210987654321:assumed-role/x with %s", secrets[0]))
+	ds.errors.addMessage("query ListEntities", "acct_123456789012 role123456789012 ids 123456789012,210987654321")
+	ds.errors.addMessage("query GetEntityHistory", "throttled at 1636070400000 for 210987654321")
+
+	// resource failures are recorded too
+	rsp := callResource(t, ds, "token", nil)
+	require.Equal(t, http.StatusBadRequest, rsp.Status)
+
+	t.Run("requires admin", func(t *testing.T) {
+		rsp := callResource(t, ds, "admin/support-bundle", nil)
+		require.Equal(t, http.StatusForbidden, rsp.Status)
+
+		rsp = callResource(t, ds, "admin/support-bundle", &backend.User{Login: "viewer", Role: "Viewer"})
+		require.Equal(t, http.StatusForbidden, rsp.Status)
+	})
+
+	t.Run("bundle is redacted", func(t *testing.T) {
+		rsp := callResource(t, ds, "admin/support-bundle", &backend.User{Login: "admin", Role: "Admin"})
+		require.Equal(t, http.StatusOK, rsp.Status)
+
+		body := string(rsp.Body)
+		for _, secret := range secrets {
+			require.NotContains(t, body, secret)
+		}
+		require.False(t, regexp.MustCompile(`(^|\D)\d{12}(\D|$)`).MatchString(body), "found an account id: %s", body)
+
+		bundle := supportBundle{}
+		require.NoError(t, json.Unmarshal(rsp.Body, &bundle))
+		require.Equal(t, "arn:aws:iam::************:role/TwinMakerDashboardRole", bundle.Settings.AssumeRoleARN)
+		require.Equal(t, redacted, bundle.Settings.AccessKey)
+		require.Equal(t, redacted, bundle.Settings.SecretKey)
+		require.Equal(t, redacted, bundle.Settings.SessionToken)
+		require.Equal(t, redacted, bundle.Settings.ExternalID)
+		require.Equal(t, "keys", bundle.Settings.AuthType)
+		require.Equal(t, "CookieFactory", bundle.Settings.WorkspaceID)
+		require.Equal(t, "1h0m0s", bundle.Settings.SessionDuration)
+		require.Equal(t, "5m0s", bundle.Settings.CacheTTL)
+
+		require.Equal(t, backend.HealthStatusError.String(), bundle.Health.Status)
+		require.Equal(t, "Failed to get session token", bundle.Health.Message)
+
+		// the health check made for the bundle is not recorded
+		require.Len(t, bundle.RecentErrors, 4)
+		require.Equal(t, "query GetAlarms", bundle.RecentErrors[0].Source)
+		require.Equal(t, "AccessDenied: arn:aws:sts::************:assumed-role/x with "+redacted, bundle.RecentErrors[0].Message)
+		require.Equal(t, "query ListEntities", bundle.RecentErrors[1].Source)
+		require.Equal(t, "acct_************ role************ ids ************,************", bundle.RecentErrors[1].Message)
+		require.Equal(t, "throttled at 1636070400000 for ************", bundle.RecentErrors[2].Message)
+		require.Equal(t, "resource /token", bundle.RecentErrors[3].Source)
+		require.Contains(t, bundle.RecentErrors[3].Message, "missing.json")
+
+		require.Equal(t, []twinmaker.CacheStats{
+			{Name: "client", Items: 0, TTL: "5m0s"},
+			{Name: "resource", Items: 0, TTL: "5m0s"},
+		}, bundle.Caches)
+		require.Equal(t, twinmaker.EffectiveRetrySettings(), bundle.Retry)
+		require.NotEmpty(t, bundle.Versions.AWSSDK)
+		require.NotEmpty(t, bundle.Versions.Go)
+	})
+}
+
+func TestSupportRedactor(t *testing.T) {
+	r := &supportRedactor{secrets: []string{"SECRET"}}
+	require.Equal(t, "************", r.redact("123456789012"))
+	require.Equal(t, "x************y", r.redact("x123456789012y"))
+	require.Equal(t, "with "+redacted, r.redact("with SECRET"))
+
+	// longer or shorter runs of digits are not account ids
+	require.Equal(t, "1234567890123 12345678901", r.redact("1234567890123 12345678901"))
+}
+
+func TestRecentErrors(t *testing.T) {
+	r := newRecentErrors(3)
+	require.Empty(t, r.list())
+
+	r.add("query", nil) // ignored
+	for i := 0; i < 5; i++ {
+		r.addMessage("query", fmt.Sprintf("error %d", i))
+	}
+
+	msgs := []string{}
+	for _, e := range r.list() {
+		msgs = append(msgs, e.Message)
+	}
+	require.Equal(t, []string{"error 2", "error 3", "error 4"}, msgs)
+}
@@ -8,9 +8,11 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	awsclient "github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/iottwinmaker"
+	"github.com/aws/aws-sdk-go/service/iottwinmaker/iottwinmakeriface"
 	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-iot-twinmaker-app/pkg/models"
@@ -35,11 +37,38 @@ type TwinMakerClient interface {
 	GetPropertyValueHistory(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.GetPropertyValueHistoryOutput, error)
 }
 
+// RetrySettings describes how failed AWS requests are retried
+type RetrySettings struct {
+	MaxRetries       int    `json:"maxRetries"`
+	MinRetryDelay    string `json:"minRetryDelay"`
+	MaxRetryDelay    string `json:"maxRetryDelay"`
+	MinThrottleDelay string `json:"minThrottleDelay"`
+	MaxThrottleDelay string `json:"maxThrottleDelay"`
+	RateLimiter      string `json:"rateLimiter"`
+}
+
+// EffectiveRetrySettings returns the retry behavior used by the clients.  The services
+// are created without a retryer override so they use the SDK default retryer, and
+// there is no client side rate limiting.
+func EffectiveRetrySettings() RetrySettings {
+	return RetrySettings{
+		MaxRetries:       awsclient.DefaultRetryerMaxNumRetries,
+		MinRetryDelay:    awsclient.DefaultRetryerMinRetryDelay.String(),
+		MaxRetryDelay:    awsclient.DefaultRetryerMaxRetryDelay.String(),
+		MinThrottleDelay: awsclient.DefaultRetryerMinThrottleDelay.String(),
+		MaxThrottleDelay: awsclient.DefaultRetryerMaxThrottleDelay.String(),
+		RateLimiter:      "none",
+	}
+}
+
 type twinMakerClient struct {
 	tokenRole string
 
 	twinMakerService func() (*iottwinmaker.IoTTwinMaker, error)
 	tokenService     func() (*sts.STS, error)
+
+	// used for the API calls, so tests can replace the service
+	twinMakerAPI func() (iottwinmakeriface.IoTTwinMakerAPI, error)
 }
 
 // NewTwinMakerClient provides a twinMakerClient for the session and associated calls
@@ -50,7 +79,7 @@ func NewTwinMakerClient(settings models.TwinMakerDataSourceSetting) (TwinMakerCl
 	// STS client can not use scoped down role to generate tokens
 	stssettings := settings.AWSDatasourceSettings
 	stssettings.AssumeRoleARN = ""
-	stssettings.Endpoint = "" // always standard
+	stssettings.Endpoint = settings.STSEndpoint // standard unless explicitly configured
 
 	twinMakerService := func() (*iottwinmaker.IoTTwinMaker, error) {
 		sess, err := sessions.GetSession("", settings.AWSDatasourceSettings)
@@ -78,15 +107,20 @@ func NewTwinMakerClient(settings models.TwinMakerDataSourceSetting) (TwinMakerCl
 		return svc, err
 	}
 
+	twinMakerAPI := func() (iottwinmakeriface.IoTTwinMakerAPI, error) {
+		return twinMakerService()
+	}
+
 	return &twinMakerClient{
 		twinMakerService: twinMakerService,
+		twinMakerAPI:     twinMakerAPI,
 		tokenService:     tokenService,
 		tokenRole:        settings.AWSDatasourceSettings.AssumeRoleARN,
 	}, nil
 }
 
 func (c *twinMakerClient) ListWorkspaces(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.ListWorkspacesOutput, error) {
-	client, err := c.twinMakerService()
+	client, err := c.twinMakerAPI()
 	if err != nil {
 		return nil, err
 	}
@@ -101,9 +135,9 @@ func (c *twinMakerClient) ListWorkspaces(ctx context.Context, query models.TwinM
 		return nil, err
 	}
 
-	cWorkspaces := workspaces
-	for cWorkspaces.NextToken != nil {
-		params.NextToken = cWorkspaces.NextToken
+	tokens := newPageTokens(ctx, "workspaces", query.NextToken)
+	for tokens.follow(workspaces.NextToken) {
+		params.NextToken = workspaces.NextToken
 
 		cWorkspaces, err := client.ListWorkspacesWithContext(ctx, params)
 		if err != nil {
@@ -113,12 +147,13 @@ func (c *twinMakerClient) ListWorkspaces(ctx context.Context, query models.TwinM
 		workspaces.WorkspaceSummaries = append(workspaces.WorkspaceSummaries, cWorkspaces.WorkspaceSummaries...)
 		workspaces.NextToken = cWorkspaces.NextToken
 	}
+	workspaces.NextToken = nil // every page was followed
 
 	return workspaces, nil
 }
 
 func (c *twinMakerClient) ListScenes(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.ListScenesOutput, error) {
-	client, err := c.twinMakerService()
+	client, err := c.twinMakerAPI()
 	if err != nil {
 		return nil, err
 	}
@@ -135,9 +170,9 @@ func (c *twinMakerClient) ListScenes(ctx context.Context, query models.TwinMaker
 		return nil, err
 	}
 
-	cScenes := scenes
-	for cScenes.NextToken != nil {
-		params.NextToken = cScenes.NextToken
+	tokens := newPageTokens(ctx, "scenes", query.NextToken)
+	for tokens.follow(scenes.NextToken) {
+		params.NextToken = scenes.NextToken
 
 		cScenes, err := client.ListScenesWithContext(ctx, params)
 		if err != nil {
@@ -147,12 +182,13 @@ func (c *twinMakerClient) ListScenes(ctx context.Context, query models.TwinMaker
 		scenes.SceneSummaries = append(scenes.SceneSummaries, cScenes.SceneSummaries...)
 		scenes.NextToken = cScenes.NextToken
 	}
+	scenes.NextToken = nil // every page was followed
 
 	return scenes, nil
 }
 
 func (c *twinMakerClient) ListEntities(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.ListEntitiesOutput, error) {
-	client, err := c.twinMakerService()
+	client, err := c.twinMakerAPI()
 	if err != nil {
 		return nil, err
 	}
@@ -175,9 +211,9 @@ func (c *twinMakerClient) ListEntities(ctx context.Context, query models.TwinMak
 		return nil, err
 	}
 
-	cEntities := entities
-	for cEntities.NextToken != nil {
-		params.NextToken = cEntities.NextToken
+	tokens := newPageTokens(ctx, "entities", query.NextToken)
+	for tokens.follow(entities.NextToken) {
+		params.NextToken = entities.NextToken
 
 		cEntities, err := client.ListEntitiesWithContext(ctx, params)
 		if err != nil {
@@ -187,12 +223,13 @@ func (c *twinMakerClient) ListEntities(ctx context.Context, query models.TwinMak
 		entities.EntitySummaries = append(entities.EntitySummaries, cEntities.EntitySummaries...)
 		entities.NextToken = cEntities.NextToken
 	}
+	entities.NextToken = nil // every page was followed
 
 	return entities, nil
 }
 
 func (c *twinMakerClient) ListComponentTypes(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.ListComponentTypesOutput, error) {
-	client, err := c.twinMakerService()
+	client, err := c.twinMakerAPI()
 	if err != nil {
 		return nil, err
 	}
@@ -215,9 +252,9 @@ func (c *twinMakerClient) ListComponentTypes(ctx context.Context, query models.T
 		return nil, err
 	}
 
-	cComponentTypes := componentTypes
-	for cComponentTypes.NextToken != nil {
-		params.NextToken = cComponentTypes.NextToken
+	tokens := newPageTokens(ctx, "component types", query.NextToken)
+	for tokens.follow(componentTypes.NextToken) {
+		params.NextToken = componentTypes.NextToken
 
 		cComponentTypes, err := client.ListComponentTypesWithContext(ctx, params)
 		if err != nil {
@@ -227,12 +264,13 @@ func (c *twinMakerClient) ListComponentTypes(ctx context.Context, query models.T
 		componentTypes.ComponentTypeSummaries = append(componentTypes.ComponentTypeSummaries, cComponentTypes.ComponentTypeSummaries...)
 		componentTypes.NextToken = cComponentTypes.NextToken
 	}
+	componentTypes.NextToken = nil // every page was followed
 
 	return componentTypes, nil
 }
 
 func (c *twinMakerClient) GetComponentType(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.GetComponentTypeOutput, error) {
-	client, err := c.twinMakerService()
+	client, err := c.twinMakerAPI()
 	if err != nil {
 		return nil, err
 	}
@@ -250,7 +288,7 @@ func (c *twinMakerClient) GetComponentType(ctx context.Context, query models.Twi
 }
 
 func (c *twinMakerClient) GetEntity(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.GetEntityOutput, error) {
-	client, err := c.twinMakerService()
+	client, err := c.twinMakerAPI()
 	if err != nil {
 		return nil, err
 	}
@@ -268,7 +306,7 @@ func (c *twinMakerClient) GetEntity(ctx context.Context, query models.TwinMakerQ
 }
 
 func (c *twinMakerClient) GetWorkspace(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.GetWorkspaceOutput, error) {
-	client, err := c.twinMakerService()
+	client, err := c.twinMakerAPI()
 	if err != nil {
 		return nil, err
 	}
@@ -281,7 +319,7 @@ func (c *twinMakerClient) GetWorkspace(ctx context.Context, query models.TwinMak
 }
 
 func (c *twinMakerClient) GetPropertyValue(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.GetPropertyValueOutput, error) {
-	client, err := c.twinMakerService()
+	client, err := c.twinMakerAPI()
 	if err != nil {
 		return nil, err
 	}
@@ -307,7 +345,7 @@ func (c *twinMakerClient) GetPropertyValue(ctx context.Context, query models.Twi
 }
 
 func (c *twinMakerClient) GetPropertyValueHistory(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.GetPropertyValueHistoryOutput, error) {
-	client, err := c.twinMakerService()
+	client, err := c.twinMakerAPI()
 	if err != nil {
 		return nil, err
 	}
@@ -331,6 +369,10 @@ func (c *twinMakerClient) GetPropertyValueHistory(ctx context.Context, query mod
 		params.SetOrderByTime(query.Order)
 	}
 
+	if query.MaxResults > 0 {
+		params.SetMaxResults(query.MaxResults)
+	}
+
 	if c := query.ComponentTypeId; c != "" {
 		if query.Properties == nil || len(query.Properties) < 1 {
 			return nil, fmt.Errorf("missing property")
@@ -11,38 +11,71 @@ import (
 	"github.com/patrickmn/go-cache"
 )
 
+// CacheStats describes the state of a cache for diagnostics
+type CacheStats struct {
+	Name  string `json:"name"`
+	Items int    `json:"items"`
+	TTL   string `json:"ttl"`
+}
+
+// CacheStatsProvider is implemented by the caching client and resources
+type CacheStatsProvider interface {
+	CacheStats() CacheStats
+}
+
 type cachingClient struct {
 	client       TwinMakerClient
 	generalCache cache.Cache
+	ttl          time.Duration
 }
 
 func NewCachingClient(client TwinMakerClient, ttl time.Duration) TwinMakerClient {
 	return &cachingClient{
 		client:       client,
 		generalCache: *cache.New(ttl, ttl*2),
+		ttl:          ttl,
 	}
 }
 
-func (c *cachingClient) getOrExecuteQuery(key string, runner func() (interface{}, error)) (interface{}, error) {
+func (c *cachingClient) CacheStats() CacheStats {
+	return CacheStats{
+		Name:  "client",
+		Items: c.generalCache.ItemCount(),
+		TTL:   c.ttl.String(),
+	}
+}
+
+// cachedResult keeps the pagination restarts seen while fetching the value, so
+// they are reported again when the value comes from the cache
+type cachedResult struct {
+	value    interface{}
+	restarts int
+}
+
+func (c *cachingClient) getOrExecuteQuery(ctx context.Context, key string, runner func(ctx context.Context) (interface{}, error)) (interface{}, error) {
 	if key == "" {
-		return runner()
+		return runner(ctx)
 	}
 	val, ok := c.generalCache.Get(key)
 	if ok {
 		backend.Logger.Debug("using cached value", "key", key)
-		return val, nil
+		cached := val.(cachedResult)
+		addPaginationRestarts(ctx, cached.restarts)
+		return cached.value, nil
 	}
-	val, err := runner()
+	fetchCtx, pages := withPaginationStats(ctx)
+	val, err := runner(fetchCtx)
 	if err == nil {
-		c.generalCache.Set(key, val, 0)
+		c.generalCache.Set(key, cachedResult{value: val, restarts: pages.Restarts()}, 0)
 	}
+	addPaginationRestarts(ctx, pages.Restarts())
 	return val, nil
 }
 
 func (c *cachingClient) ListWorkspaces(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.ListWorkspacesOutput, error) {
-	val, err := c.getOrExecuteQuery(
+	val, err := c.getOrExecuteQuery(ctx,
 		query.CacheKey("ListWorkspace"),
-		func() (interface{}, error) {
+		func(ctx context.Context) (interface{}, error) {
 			return c.client.ListWorkspaces(ctx, query)
 		},
 	)
@@ -56,9 +89,9 @@ func (c *cachingClient) ListWorkspaces(ctx context.Context, query models.TwinMak
 }
 
 func (c *cachingClient) ListScenes(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.ListScenesOutput, error) {
-	val, err := c.getOrExecuteQuery(
+	val, err := c.getOrExecuteQuery(ctx,
 		query.CacheKey("ListScenes"),
-		func() (interface{}, error) {
+		func(ctx context.Context) (interface{}, error) {
 			return c.client.ListScenes(ctx, query)
 		},
 	)
@@ -72,9 +105,9 @@ func (c *cachingClient) ListScenes(ctx context.Context, query models.TwinMakerQu
 }
 
 func (c *cachingClient) ListEntities(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.ListEntitiesOutput, error) {
-	val, err := c.getOrExecuteQuery(
+	val, err := c.getOrExecuteQuery(ctx,
 		query.CacheKey("ListEntities"),
-		func() (interface{}, error) {
+		func(ctx context.Context) (interface{}, error) {
 			return c.client.ListEntities(ctx, query)
 		},
 	)
@@ -88,9 +121,9 @@ func (c *cachingClient) ListEntities(ctx context.Context, query models.TwinMaker
 }
 
 func (c *cachingClient) ListComponentTypes(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.ListComponentTypesOutput, error) {
-	val, err := c.getOrExecuteQuery(
+	val, err := c.getOrExecuteQuery(ctx,
 		query.CacheKey("ListComponentTypes"),
-		func() (interface{}, error) {
+		func(ctx context.Context) (interface{}, error) {
 			return c.client.ListComponentTypes(ctx, query)
 		},
 	)
@@ -104,9 +137,9 @@ func (c *cachingClient) ListComponentTypes(ctx context.Context, query models.Twi
 }
 
 func (c *cachingClient) GetComponentType(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.GetComponentTypeOutput, error) {
-	val, err := c.getOrExecuteQuery(
+	val, err := c.getOrExecuteQuery(ctx,
 		query.CacheKey("GetComponentType"),
-		func() (interface{}, error) {
+		func(ctx context.Context) (interface{}, error) {
 			return c.client.GetComponentType(ctx, query)
 		},
 	)
@@ -120,9 +153,9 @@ func (c *cachingClient) GetComponentType(ctx context.Context, query models.TwinM
 }
 
 func (c *cachingClient) GetEntity(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.GetEntityOutput, error) {
-	val, err := c.getOrExecuteQuery(
+	val, err := c.getOrExecuteQuery(ctx,
 		query.CacheKey("GetEntity"),
-		func() (interface{}, error) {
+		func(ctx context.Context) (interface{}, error) {
 			return c.client.GetEntity(ctx, query)
 		},
 	)
@@ -136,9 +169,9 @@ func (c *cachingClient) GetEntity(ctx context.Context, query models.TwinMakerQue
 }
 
 func (c *cachingClient) GetWorkspace(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.GetWorkspaceOutput, error) {
-	val, err := c.getOrExecuteQuery(
+	val, err := c.getOrExecuteQuery(ctx,
 		query.CacheKey("GetWorkspace"),
-		func() (interface{}, error) {
+		func(ctx context.Context) (interface{}, error) {
 			return c.client.GetWorkspace(ctx, query)
 		},
 	)
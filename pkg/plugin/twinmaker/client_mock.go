@@ -3,16 +3,26 @@ package twinmaker
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/iottwinmaker"
+	"github.com/aws/aws-sdk-go/service/iottwinmaker/iottwinmakeriface"
 	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/grafana/grafana-iot-twinmaker-app/pkg/models"
 )
 
 type twinMakerMockClient struct {
 	path string
+
+	// responses are used in order, one per call, before falling back to path
+	responses []string
+
+	// queries sent to GetPropertyValueHistory
+	historyQueries []models.TwinMakerQuery
 }
 
 // NewTwinMakerMockClient provides a mock twinMakerMockClient for the session and associated calls
@@ -23,7 +33,12 @@ func NewTwinMakerMockClient(path string) (*twinMakerMockClient, error) {
 }
 
 func (c *twinMakerMockClient) loadSavedResponse(r interface{}) (interface{}, error) {
-	bs, err := ioutil.ReadFile("./testdata/" + c.path + ".json")
+	path := c.path
+	if len(c.responses) > 0 {
+		path, c.responses = c.responses[0], c.responses[1:]
+	}
+
+	bs, err := ioutil.ReadFile("./testdata/" + path + ".json")
 	if err != nil {
 		return nil, err
 	}
@@ -81,6 +96,7 @@ func (c *twinMakerMockClient) GetPropertyValue(ctx context.Context, query models
 }
 
 func (c *twinMakerMockClient) GetPropertyValueHistory(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.GetPropertyValueHistoryOutput, error) {
+	c.historyQueries = append(c.historyQueries, query)
 	r := &iottwinmaker.GetPropertyValueHistoryOutput{}
 	_, err := c.loadSavedResponse(r)
 	return r, err
@@ -91,3 +107,68 @@ func (c *twinMakerMockClient) GetSessionToken(ctx context.Context, duration time
 	_, err := c.loadSavedResponse(r)
 	return r, err
 }
+
+// twinMakerPagedMockService serves the list calls from pages keyed by the requested token,
+// so the client pagination loops run against repeated pages and tokens
+type twinMakerPagedMockService struct {
+	iottwinmakeriface.IoTTwinMakerAPI
+
+	workspaces     map[string]*iottwinmaker.ListWorkspacesOutput
+	scenes         map[string]*iottwinmaker.ListScenesOutput
+	entities       map[string]*iottwinmaker.ListEntitiesOutput
+	componentTypes map[string]*iottwinmaker.ListComponentTypesOutput
+
+	// tokens in the order they were requested
+	requested []string
+}
+
+// newTwinMakerPagedMockClient provides a twinMakerClient that calls the paged mock service
+func newTwinMakerPagedMockClient(service *twinMakerPagedMockService) *twinMakerClient {
+	return &twinMakerClient{
+		twinMakerAPI: func() (iottwinmakeriface.IoTTwinMakerAPI, error) {
+			return service, nil
+		},
+	}
+}
+
+func (c *twinMakerPagedMockService) request(token *string) string {
+	t := aws.StringValue(token)
+	c.requested = append(c.requested, t)
+	return t
+}
+
+func (c *twinMakerPagedMockService) ListWorkspacesWithContext(ctx aws.Context, input *iottwinmaker.ListWorkspacesInput, opts ...request.Option) (*iottwinmaker.ListWorkspacesOutput, error) {
+	page, ok := c.workspaces[c.request(input.NextToken)]
+	if !ok {
+		return nil, fmt.Errorf("unexpected token %q", aws.StringValue(input.NextToken))
+	}
+	r := *page
+	return &r, nil
+}
+
+func (c *twinMakerPagedMockService) ListScenesWithContext(ctx aws.Context, input *iottwinmaker.ListScenesInput, opts ...request.Option) (*iottwinmaker.ListScenesOutput, error) {
+	page, ok := c.scenes[c.request(input.NextToken)]
+	if !ok {
+		return nil, fmt.Errorf("unexpected token %q", aws.StringValue(input.NextToken))
+	}
+	r := *page
+	return &r, nil
+}
+
+func (c *twinMakerPagedMockService) ListEntitiesWithContext(ctx aws.Context, input *iottwinmaker.ListEntitiesInput, opts ...request.Option) (*iottwinmaker.ListEntitiesOutput, error) {
+	page, ok := c.entities[c.request(input.NextToken)]
+	if !ok {
+		return nil, fmt.Errorf("unexpected token %q", aws.StringValue(input.NextToken))
+	}
+	r := *page
+	return &r, nil
+}
+
+func (c *twinMakerPagedMockService) ListComponentTypesWithContext(ctx aws.Context, input *iottwinmaker.ListComponentTypesInput, opts ...request.Option) (*iottwinmaker.ListComponentTypesOutput, error) {
+	page, ok := c.componentTypes[c.request(input.NextToken)]
+	if !ok {
+		return nil, fmt.Errorf("unexpected token %q", aws.StringValue(input.NextToken))
+	}
+	r := *page
+	return &r, nil
+}
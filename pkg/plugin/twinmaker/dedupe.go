@@ -0,0 +1,124 @@
+package twinmaker
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iottwinmaker"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+type paginationStatsKey struct{}
+
+// paginationStats counts the pagination restarts of the list calls made with its context
+type paginationStats struct {
+	restarts int32
+}
+
+// withPaginationStats returns a context that collects the pagination restarts
+func withPaginationStats(ctx context.Context) (context.Context, *paginationStats) {
+	stats := &paginationStats{}
+	return context.WithValue(ctx, paginationStatsKey{}, stats), stats
+}
+
+// addPaginationRestarts records restarts on the stats of the context, if any
+func addPaginationRestarts(ctx context.Context, count int) {
+	if stats, ok := ctx.Value(paginationStatsKey{}).(*paginationStats); ok && count > 0 {
+		atomic.AddInt32(&stats.restarts, int32(count))
+	}
+}
+
+func (p *paginationStats) Restarts() int {
+	return int(atomic.LoadInt32(&p.restarts))
+}
+
+// pageTokens guards the pagination loops against restarts, where the service hands
+// back a token that was already followed and the same page would be appended twice
+type pageTokens struct {
+	ctx  context.Context
+	kind string
+	seen map[string]bool
+}
+
+func newPageTokens(ctx context.Context, kind string, first string) *pageTokens {
+	return &pageTokens{
+		ctx:  ctx,
+		kind: kind,
+		seen: map[string]bool{first: true},
+	}
+}
+
+// follow returns true when the token should be fetched
+func (p *pageTokens) follow(token *string) bool {
+	if token == nil {
+		return false
+	}
+	if p.seen[*token] {
+		backend.Logger.Debug("pagination restarted, ignoring repeated token", "kind", p.kind, "token", *token)
+		addPaginationRestarts(p.ctx, 1)
+		return false
+	}
+	p.seen[*token] = true
+	return true
+}
+
+// The dedupe functions keep the first summary for each id and return the number
+// of duplicates dropped.  Duplicates point to pagination anomalies in the backend
+// so they are logged as well.
+
+func dedupeEntitySummaries(summaries []*iottwinmaker.EntitySummary) ([]*iottwinmaker.EntitySummary, int) {
+	unique := uniqueEntitySummaries(summaries)
+	return unique, logDuplicates("entities", len(summaries)-len(unique))
+}
+
+// uniqueEntitySummaries drops duplicates without logging, for merged lists where
+// they are expected
+func uniqueEntitySummaries(summaries []*iottwinmaker.EntitySummary) []*iottwinmaker.EntitySummary {
+	seen := make(map[string]bool, len(summaries))
+	unique := make([]*iottwinmaker.EntitySummary, 0, len(summaries))
+	for _, s := range summaries {
+		id := aws.StringValue(s.EntityId)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, s)
+	}
+	return unique
+}
+
+func dedupeSceneSummaries(summaries []*iottwinmaker.SceneSummary) ([]*iottwinmaker.SceneSummary, int) {
+	seen := make(map[string]bool, len(summaries))
+	unique := make([]*iottwinmaker.SceneSummary, 0, len(summaries))
+	for _, s := range summaries {
+		id := aws.StringValue(s.SceneId)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, s)
+	}
+	return unique, logDuplicates("scenes", len(summaries)-len(unique))
+}
+
+func dedupeComponentTypeSummaries(summaries []*iottwinmaker.ComponentTypeSummary) ([]*iottwinmaker.ComponentTypeSummary, int) {
+	seen := make(map[string]bool, len(summaries))
+	unique := make([]*iottwinmaker.ComponentTypeSummary, 0, len(summaries))
+	for _, s := range summaries {
+		id := aws.StringValue(s.ComponentTypeId)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, s)
+	}
+	return unique, logDuplicates("componentTypes", len(summaries)-len(unique))
+}
+
+func logDuplicates(kind string, count int) int {
+	if count > 0 {
+		backend.Logger.Debug("dropped duplicate summaries", "kind", kind, "count", count)
+	}
+	return count
+}
@@ -0,0 +1,141 @@
+package twinmaker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iottwinmaker"
+	"github.com/grafana/grafana-iot-twinmaker-app/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageTokens(t *testing.T) {
+	ctx, stats := withPaginationStats(context.Background())
+	tokens := newPageTokens(ctx, "scenes", "")
+	require.False(t, tokens.follow(nil))
+	require.True(t, tokens.follow(aws.String("page2")))
+	require.True(t, tokens.follow(aws.String("page3")))
+	require.Equal(t, 0, stats.Restarts())
+
+	// the service restarted pagination
+	require.False(t, tokens.follow(aws.String("page2")))
+	require.Equal(t, 1, stats.Restarts())
+
+	// restarts are not recorded without stats on the context
+	require.False(t, newPageTokens(context.Background(), "scenes", "page2").follow(aws.String("page2")))
+}
+
+func TestPaginationRestarts(t *testing.T) {
+	created := aws.Time(time.Date(2021, 11, 16, 18, 53, 20, 0, time.UTC))
+	entity := func(id string) *iottwinmaker.EntitySummary {
+		return &iottwinmaker.EntitySummary{EntityId: aws.String(id), EntityName: aws.String(id), CreationDateTime: created}
+	}
+	scene := func(id string) *iottwinmaker.SceneSummary {
+		return &iottwinmaker.SceneSummary{SceneId: aws.String(id), CreationDateTime: created}
+	}
+	componentType := func(id string) *iottwinmaker.ComponentTypeSummary {
+		return &iottwinmaker.ComponentTypeSummary{ComponentTypeId: aws.String(id), CreationDateTime: created}
+	}
+
+	t.Run("entities with a repeated token", func(t *testing.T) {
+		service := &twinMakerPagedMockService{
+			entities: map[string]*iottwinmaker.ListEntitiesOutput{
+				"":      {EntitySummaries: []*iottwinmaker.EntitySummary{entity("A"), entity("B")}, NextToken: aws.String("page2")},
+				"page2": {EntitySummaries: []*iottwinmaker.EntitySummary{entity("B"), entity("C")}, NextToken: aws.String("page3")},
+				"page3": {EntitySummaries: []*iottwinmaker.EntitySummary{entity("D")}, NextToken: aws.String("page2")},
+			},
+		}
+		client := newTwinMakerPagedMockClient(service)
+
+		ctx, stats := withPaginationStats(context.Background())
+		entities, err := client.ListEntities(ctx, models.TwinMakerQuery{WorkspaceId: "CookieFactory-11-16"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"", "page2", "page3"}, service.requested)
+		require.Len(t, entities.EntitySummaries, 5)
+		require.Nil(t, entities.NextToken)
+		require.Equal(t, 1, stats.Restarts())
+
+		service.requested = nil
+		dr := NewTwinMakerHandler(client).ListEntities(context.Background(), models.TwinMakerQuery{WorkspaceId: "CookieFactory-11-16"})
+		require.NoError(t, dr.Error)
+		require.Equal(t, 4, dr.Frames[0].Rows())
+		require.Equal(t, models.TwinMakerCustomMeta{DuplicatesDropped: 1, PaginationRestarts: 1}, dr.Frames[0].Meta.Custom)
+	})
+
+	t.Run("cached entities keep the restart count", func(t *testing.T) {
+		service := &twinMakerPagedMockService{
+			entities: map[string]*iottwinmaker.ListEntitiesOutput{
+				"":      {EntitySummaries: []*iottwinmaker.EntitySummary{entity("A")}, NextToken: aws.String("page2")},
+				"page2": {EntitySummaries: []*iottwinmaker.EntitySummary{entity("A")}, NextToken: aws.String("page2")},
+			},
+		}
+		handler := NewTwinMakerHandler(NewCachingClient(newTwinMakerPagedMockClient(service), time.Minute))
+		query := models.TwinMakerQuery{WorkspaceId: "CookieFactory-11-16"}
+		expected := models.TwinMakerCustomMeta{DuplicatesDropped: 1, PaginationRestarts: 1}
+
+		dr := handler.ListEntities(context.Background(), query)
+		require.NoError(t, dr.Error)
+		require.Equal(t, expected, dr.Frames[0].Meta.Custom)
+
+		dr = handler.ListEntities(context.Background(), query)
+		require.NoError(t, dr.Error)
+		require.Equal(t, []string{"", "page2"}, service.requested) // served from the cache
+		require.Equal(t, expected, dr.Frames[0].Meta.Custom)
+	})
+
+	t.Run("scenes with a repeated page", func(t *testing.T) {
+		service := &twinMakerPagedMockService{
+			scenes: map[string]*iottwinmaker.ListScenesOutput{
+				"":      {SceneSummaries: []*iottwinmaker.SceneSummary{scene("CookieFactory")}, NextToken: aws.String("page2")},
+				"page2": {SceneSummaries: []*iottwinmaker.SceneSummary{scene("CookieFactory")}, NextToken: aws.String("page2")},
+			},
+		}
+		dr := NewTwinMakerHandler(newTwinMakerPagedMockClient(service)).ListScenes(context.Background(), models.TwinMakerQuery{})
+		require.NoError(t, dr.Error)
+		require.Equal(t, []string{"", "page2"}, service.requested)
+		require.Equal(t, 1, dr.Frames[0].Rows())
+		require.Equal(t, models.TwinMakerCustomMeta{DuplicatesDropped: 1, PaginationRestarts: 1}, dr.Frames[0].Meta.Custom)
+	})
+
+	t.Run("component types without a restart", func(t *testing.T) {
+		service := &twinMakerPagedMockService{
+			componentTypes: map[string]*iottwinmaker.ListComponentTypesOutput{
+				"":      {ComponentTypeSummaries: []*iottwinmaker.ComponentTypeSummary{componentType("mixer")}, NextToken: aws.String("page2")},
+				"page2": {ComponentTypeSummaries: []*iottwinmaker.ComponentTypeSummary{componentType("alarm")}},
+			},
+		}
+		dr := NewTwinMakerHandler(newTwinMakerPagedMockClient(service)).ListComponentTypes(context.Background(), models.TwinMakerQuery{})
+		require.NoError(t, dr.Error)
+		require.Equal(t, []string{"", "page2"}, service.requested)
+		require.Equal(t, 2, dr.Frames[0].Rows())
+		require.Equal(t, models.TwinMakerCustomMeta{}, dr.Frames[0].Meta.Custom)
+	})
+
+	t.Run("workspaces with a repeated token", func(t *testing.T) {
+		service := &twinMakerPagedMockService{
+			workspaces: map[string]*iottwinmaker.ListWorkspacesOutput{
+				"":      {WorkspaceSummaries: []*iottwinmaker.WorkspaceSummary{{WorkspaceId: aws.String("one")}}, NextToken: aws.String("page2")},
+				"page2": {WorkspaceSummaries: []*iottwinmaker.WorkspaceSummary{{WorkspaceId: aws.String("two")}}, NextToken: aws.String("page2")},
+			},
+		}
+		ctx, stats := withPaginationStats(context.Background())
+		workspaces, err := newTwinMakerPagedMockClient(service).ListWorkspaces(ctx, models.TwinMakerQuery{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"", "page2"}, service.requested)
+		require.Len(t, workspaces.WorkspaceSummaries, 2)
+		require.Equal(t, 1, stats.Restarts())
+	})
+}
+
+func TestResourceDuplicatePages(t *testing.T) {
+	client, err := NewTwinMakerMockClient("list-scenes-duplicates")
+	require.NoError(t, err)
+	res := NewTwinMakerResource(client, "CookieFactory-11-16")
+
+	scenes, err := res.ListScenes(context.Background())
+	require.NoError(t, err)
+	require.Len(t, scenes, 1)
+	require.Equal(t, "CookieFactory", scenes[0].Value)
+}
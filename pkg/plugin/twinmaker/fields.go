@@ -11,6 +11,7 @@ import (
 type twinMakerFrameBuilder struct {
 	len    int
 	fields []*data.Field
+	meta   models.TwinMakerCustomMeta
 }
 
 func newTwinMakerFrameBuilder(len int) twinMakerFrameBuilder {
@@ -19,7 +20,7 @@ func newTwinMakerFrameBuilder(len int) twinMakerFrameBuilder {
 
 func (r *twinMakerFrameBuilder) ToFrame(name string, nextToken *string) *data.Frame {
 	f := data.NewFrame(name, r.fields...)
-	meta := models.TwinMakerCustomMeta{}
+	meta := r.meta
 	if nextToken != nil {
 		meta.NextToken = *nextToken
 	}
@@ -182,6 +183,11 @@ func (r *twinMakerFrameBuilder) AlarmStatus() *data.Field {
 	return r.add(f, "alarmStatus")
 }
 
+func (r *twinMakerFrameBuilder) Quality() *data.Field {
+	f := data.NewFieldFromFieldType(data.FieldTypeNullableString, r.len)
+	return r.add(f, "quality")
+}
+
 // // CreationDate is a required field
 // CreationDate *time.Time `locationName:"creationDate" type:"timestamp" required:"true"`
 
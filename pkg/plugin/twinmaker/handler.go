@@ -97,19 +97,23 @@ func (s *twinMakerHandler) ListWorkspaces(ctx context.Context, query models.Twin
 }
 
 func (s *twinMakerHandler) ListScenes(ctx context.Context, query models.TwinMakerQuery) (dr backend.DataResponse) {
+	ctx, pages := withPaginationStats(ctx)
 	results, err := s.client.ListScenes(ctx, query)
 	dr.Error = err
 	if err != nil {
 		return
 	}
-	fields := newTwinMakerFrameBuilder(len(results.SceneSummaries))
+	summaries, duplicates := dedupeSceneSummaries(results.SceneSummaries)
+	fields := newTwinMakerFrameBuilder(len(summaries))
+	fields.meta.DuplicatesDropped = duplicates
+	fields.meta.PaginationRestarts = pages.Restarts()
 
 	arn := fields.ARN()
 	created := fields.CreationDate()
 	description := fields.Description()
 	sceneId := fields.SceneId()
 
-	for i, summary := range results.SceneSummaries {
+	for i, summary := range summaries {
 		arn.Set(i, summary.Arn)
 		created.Set(i, *summary.CreationDateTime)
 		description.Set(i, summary.Description)
@@ -122,12 +126,16 @@ func (s *twinMakerHandler) ListScenes(ctx context.Context, query models.TwinMake
 }
 
 func (s *twinMakerHandler) ListEntities(ctx context.Context, query models.TwinMakerQuery) (dr backend.DataResponse) {
+	ctx, pages := withPaginationStats(ctx)
 	results, err := s.client.ListEntities(ctx, query)
 	dr.Error = err
 	if err != nil {
 		return
 	}
-	fields := newTwinMakerFrameBuilder(len(results.EntitySummaries))
+	summaries, duplicates := dedupeEntitySummaries(results.EntitySummaries)
+	fields := newTwinMakerFrameBuilder(len(summaries))
+	fields.meta.DuplicatesDropped = duplicates
+	fields.meta.PaginationRestarts = pages.Restarts()
 
 	entityId := fields.EntityID()
 	entityName := fields.Name()
@@ -135,7 +143,7 @@ func (s *twinMakerHandler) ListEntities(ctx context.Context, query models.TwinMa
 	created := fields.CreationDate()
 	arn := fields.ARN()
 
-	for i, summary := range results.EntitySummaries {
+	for i, summary := range summaries {
 		arn.Set(i, summary.Arn)
 		created.Set(i, *summary.CreationDateTime)
 		entityId.Set(i, summary.EntityId)
@@ -149,19 +157,23 @@ func (s *twinMakerHandler) ListEntities(ctx context.Context, query models.TwinMa
 }
 
 func (s *twinMakerHandler) ListComponentTypes(ctx context.Context, query models.TwinMakerQuery) (dr backend.DataResponse) {
+	ctx, pages := withPaginationStats(ctx)
 	results, err := s.client.ListComponentTypes(ctx, query)
 	dr.Error = err
 	if err != nil {
 		return
 	}
-	fields := newTwinMakerFrameBuilder(len(results.ComponentTypeSummaries))
+	summaries, duplicates := dedupeComponentTypeSummaries(results.ComponentTypeSummaries)
+	fields := newTwinMakerFrameBuilder(len(summaries))
+	fields.meta.DuplicatesDropped = duplicates
+	fields.meta.PaginationRestarts = pages.Restarts()
 
 	componentId := fields.ComponentID()
 	description := fields.Description()
 	created := fields.CreationDate()
 	arn := fields.ARN()
 
-	for i, summary := range results.ComponentTypeSummaries {
+	for i, summary := range summaries {
 		arn.Set(i, summary.Arn)
 		created.Set(i, *summary.CreationDateTime)
 		componentId.Set(i, summary.ComponentTypeId)
@@ -326,26 +338,52 @@ func (s *twinMakerHandler) processMapValue(v map[string]*iottwinmaker.DataValue)
 	return frame
 }
 
-func (s *twinMakerHandler) processHistory(results *iottwinmaker.GetPropertyValueHistoryOutput, err error, query models.TwinMakerQuery) (dr backend.DataResponse) {
+func (s *twinMakerHandler) processHistory(results *iottwinmaker.GetPropertyValueHistoryOutput, err error, query models.TwinMakerQuery, windows maintenanceWindows) (dr backend.DataResponse) {
 	dr.Error = err
 	if err != nil {
 		return
 	}
 
+	quality := newQualityIndex(results.PropertyValues, query)
+	suppressed := 0
+	dropped := 0
+	unmatched := 0
 	for _, prop := range results.PropertyValues {
-		if len(prop.Values) == 0 {
+		ref := prop.EntityPropertyReference
+		if quality.isHidden(ref) {
+			continue
+		}
+
+		values := prop.Values
+		var flags []*string
+		if quality.appliesTo(ref) {
+			var bad, missing int
+			values, flags, bad, missing = quality.align(ref, values)
+			dropped += bad
+			unmatched += missing
+		}
+		if len(values) == 0 {
 			continue
 		}
-		fields := newTwinMakerFrameBuilder(len(prop.Values))
+		isAlarm := ref.PropertyName != nil && *ref.PropertyName == alarmStatusProperty
+
+		fields := newTwinMakerFrameBuilder(len(values))
 		t := fields.Time()
-		v, conv := fields.Value(prop.Values[0].Value)
+		v, conv := fields.Value(values[0].Value)
 		v.Name = "" // filled in with value below
-		for i, history := range prop.Values {
+		for i, history := range values {
 			t.Set(i, history.Timestamp)
+			if isAlarm && history.Value.StringValue != nil {
+				status, ok := windows.suppress(history.Value.StringValue, history.Timestamp)
+				if ok {
+					suppressed++
+				}
+				v.Set(i, status)
+				continue
+			}
 			v.Set(i, conv(history.Value))
 		}
 
-		ref := prop.EntityPropertyReference
 		v.Labels = data.Labels{}
 		if ref.ComponentName != nil {
 			v.Labels["componentName"] = *ref.ComponentName
@@ -366,9 +404,29 @@ func (s *twinMakerHandler) processHistory(results *iottwinmaker.GetPropertyValue
 			}
 		}
 
+		if flags != nil {
+			q := fields.Quality()
+			q.Labels = v.Labels
+			for i, flag := range flags {
+				q.Set(i, flag)
+			}
+		}
+
 		frame := fields.ToFrame("", results.NextToken)
 		dr.Frames = append(dr.Frames, frame)
 	}
+
+	if len(dr.Frames) > 0 {
+		if suppressed > 0 {
+			dr.Frames[0].AppendNotices(suppressedNotice(suppressed))
+		}
+		if dropped > 0 {
+			dr.Frames[0].AppendNotices(droppedQualityNotice(dropped))
+		}
+		if unmatched > 0 {
+			dr.Frames[0].AppendNotices(unmatchedQualityNotice(unmatched))
+		}
+	}
 	return
 }
 
@@ -379,8 +437,20 @@ func (s *twinMakerHandler) GetComponentHistory(ctx context.Context, query models
 		}
 	}
 
-	result, err := s.client.GetPropertyValueHistory(ctx, query)
-	return s.processHistory(result, err, query)
+	if err := validateQuality(query.Quality); err != nil {
+		return backend.DataResponse{
+			Error: err,
+		}
+	}
+	windows, err := s.getMaintenanceWindows(ctx, query)
+	if err != nil {
+		return backend.DataResponse{
+			Error: err,
+		}
+	}
+
+	result, err := s.getHistoryForQuality(ctx, query)
+	return s.processHistory(result, err, query, windows)
 }
 
 func (s *twinMakerHandler) GetEntityHistory(ctx context.Context, query models.TwinMakerQuery) backend.DataResponse {
@@ -389,15 +459,26 @@ func (s *twinMakerHandler) GetEntityHistory(ctx context.Context, query models.Tw
 			Error: fmt.Errorf("missing entity parameter"),
 		}
 	}
-	result, err := s.client.GetPropertyValueHistory(ctx, query)
-	return s.processHistory(result, err, query)
+	if err := validateQuality(query.Quality); err != nil {
+		return backend.DataResponse{
+			Error: err,
+		}
+	}
+	windows, err := s.getMaintenanceWindows(ctx, query)
+	if err != nil {
+		return backend.DataResponse{
+			Error: err,
+		}
+	}
+
+	result, err := s.getHistoryForQuality(ctx, query)
+	return s.processHistory(result, err, query, windows)
 }
 
 // return status and value here
 func (s *twinMakerHandler) GetAlarms(ctx context.Context, query models.TwinMakerQuery) (dr backend.DataResponse) {
 	alarmComponentType := "com.amazon.iottwinmaker.alarm.basic"
 	externalIdKey := "alarm_key"
-	alarmProperty := "alarm_status"
 	isFiltered := len(query.Filter) > 0
 
 	var filter []models.TwinMakerPropertyFilter
@@ -406,6 +487,12 @@ func (s *twinMakerHandler) GetAlarms(ctx context.Context, query models.TwinMaker
 		query.Filter = nil
 	}
 
+	windows, err := s.getMaintenanceWindows(ctx, query)
+	dr.Error = err
+	if err != nil {
+		return
+	}
+
 	// Step 1 - Get all componentTypes that extend from the base alarm type
 	alarmComponentTypes := map[string]*iottwinmaker.ComponentTypeSummary{}
 	query.ComponentTypeId = alarmComponentType
@@ -417,7 +504,8 @@ func (s *twinMakerHandler) GetAlarms(ctx context.Context, query models.TwinMaker
 
 	// Step 2 - List all entities with alarm componentTypeIds as a filter
 	entitySummaries := []*iottwinmaker.EntitySummary{}
-	for _, componentTypeSummary := range componentTypes.ComponentTypeSummaries {
+	componentTypeSummaries, _ := dedupeComponentTypeSummaries(componentTypes.ComponentTypeSummaries)
+	for _, componentTypeSummary := range componentTypeSummaries {
 		// Set mapping of alarm component types for quick lookup later
 		alarmComponentTypes[*componentTypeSummary.ComponentTypeId] = componentTypeSummary
 
@@ -428,8 +516,11 @@ func (s *twinMakerHandler) GetAlarms(ctx context.Context, query models.TwinMaker
 			return
 		}
 
-		entitySummaries = append(entitySummaries, entities.EntitySummaries...)
+		summaries, _ := dedupeEntitySummaries(entities.EntitySummaries)
+		entitySummaries = append(entitySummaries, summaries...)
 	}
+	// an entity with several alarm components is listed once for each type
+	entitySummaries = uniqueEntitySummaries(entitySummaries)
 
 	// Step 3 - Call GetEntity on each alarm entity
 	alarms := map[string]alarm{}
@@ -465,7 +556,7 @@ func (s *twinMakerHandler) GetAlarms(ctx context.Context, query models.TwinMaker
 	// Step 4 - Call GetPropertyValueHistory by alarm componentType and match with fetched alarms
 	failures := []data.Notice{}
 	query.EntityId = ""
-	query.Properties = []*string{aws.String(alarmProperty)}
+	query.Properties = []*string{aws.String(alarmStatusProperty)}
 	filteredAlarms := []alarm{}
 	for componentTypeId := range alarmComponentTypes {
 		query.ComponentTypeId = componentTypeId
@@ -511,6 +602,24 @@ func (s *twinMakerHandler) GetAlarms(ctx context.Context, query models.TwinMaker
 		return strings.Compare(a1.sortString(), a2.sortString()) >= 0
 	})
 
+	// the table shows the current status, so it is suppressed when maintenance is ongoing
+	// at the end of the range rather than when the status last changed
+	shownAt := query.TimeRange.To
+	if now := time.Now(); now.Before(shownAt) {
+		shownAt = now
+	}
+	suppressed := 0
+	for i, a := range showAlarms {
+		status, ok := windows.suppress(a.status, &shownAt)
+		if ok {
+			showAlarms[i].status = status
+			suppressed++
+		}
+	}
+	if suppressed > 0 {
+		failures = append(failures, suppressedNotice(suppressed))
+	}
+
 	fields := newTwinMakerFrameBuilder(len(showAlarms))
 	name := fields.Name()
 	name.Name = "alarmName"
@@ -547,6 +656,11 @@ func (s *twinMakerHandler) GetAlarms(ctx context.Context, query models.TwinMaker
 					Index: 3,
 					Text:  "ACKNOWLEDGED",
 				},
+				alarmStatusSuppressed: {
+					Color: "purple",
+					Index: 4,
+					Text:  alarmStatusSuppressed,
+				},
 			},
 		},
 	}
@@ -50,6 +50,30 @@ func TestHandleAWSData(t *testing.T) {
 		_ = runTest(t, client.path, &resp)
 	})
 
+	t.Run("run ListEntities handler w duplicate pages", func(t *testing.T) {
+		client.path = "list-entities-duplicates"
+		resp := handler.ListEntities(context.Background(), models.TwinMakerQuery{})
+		dr := runTest(t, client.path, &resp)
+		require.Equal(t, 5, dr.Frames[0].Rows())
+		require.Equal(t, 2, dr.Frames[0].Meta.Custom.(models.TwinMakerCustomMeta).DuplicatesDropped)
+	})
+
+	t.Run("run ListScenes handler w duplicate pages", func(t *testing.T) {
+		client.path = "list-scenes-duplicates"
+		resp := handler.ListScenes(context.Background(), models.TwinMakerQuery{})
+		dr := runTest(t, client.path, &resp)
+		require.Equal(t, 1, dr.Frames[0].Rows())
+		require.Equal(t, 1, dr.Frames[0].Meta.Custom.(models.TwinMakerCustomMeta).DuplicatesDropped)
+	})
+
+	t.Run("run ListComponentTypes handler w duplicate pages", func(t *testing.T) {
+		client.path = "list-component-types-duplicates"
+		resp := handler.ListComponentTypes(context.Background(), models.TwinMakerQuery{})
+		dr := runTest(t, client.path, &resp)
+		require.Equal(t, 4, dr.Frames[0].Rows())
+		require.Equal(t, 2, dr.Frames[0].Meta.Custom.(models.TwinMakerCustomMeta).DuplicatesDropped)
+	})
+
 	t.Run("run GetEntity handler", func(t *testing.T) {
 		client.path = "get-entity"
 		resp := handler.GetEntity(context.Background(), models.TwinMakerQuery{})
@@ -136,6 +160,47 @@ func TestHandleAWSData(t *testing.T) {
 		})
 		_ = runTest(t, client.path, &resp)
 	})
+
+	t.Run("run GetAlarms handler w maintenance suppression", func(t *testing.T) {
+		start := time.Date(2021, 11, 5, 0, 0, 0, 0, time.UTC)
+		getAlarms := func(window models.TwinMakerTimeWindow) backend.DataResponse {
+			client := &twinMakerMockClient{
+				responses: []string{
+					"get-alarms-suppressed-component-types",
+					"get-alarms-suppressed-entities",
+					"get-entity",
+					"get-alarms-suppressed-history",
+				},
+			}
+			return NewTwinMakerHandler(client).GetAlarms(context.Background(), models.TwinMakerQuery{
+				WorkspaceId: "CookieFactory-11-16",
+				TimeRange: backend.TimeRange{
+					From: start,
+					To:   start.Add(5 * time.Hour),
+				},
+				Suppression: &models.TwinMakerSuppression{
+					Enabled: true,
+					Windows: []models.TwinMakerTimeWindow{window},
+				},
+			})
+		}
+
+		// the alarm went ACTIVE at 01:00, before the window opened, and is still in maintenance at the end of the range
+		resp := getAlarms(models.TwinMakerTimeWindow{From: start.Add(3 * time.Hour)})
+		require.NoError(t, resp.Error)
+		require.Len(t, resp.Frames, 1)
+		require.Equal(t, 1, resp.Frames[0].Rows())
+		status := resp.Frames[0].Fields[4]
+		require.Equal(t, "SUPPRESSED", *(status.At(0).(*string)))
+		require.Equal(t, []data.Notice{suppressedNotice(1)}, resp.Frames[0].Meta.Notices)
+
+		// maintenance ended before the end of the range
+		resp = getAlarms(models.TwinMakerTimeWindow{From: start.Add(30 * time.Minute), To: start.Add(2 * time.Hour)})
+		require.NoError(t, resp.Error)
+		status = resp.Frames[0].Fields[4]
+		require.Equal(t, "ACTIVE", *(status.At(0).(*string)))
+		require.Empty(t, resp.Frames[0].Meta.Notices)
+	})
 }
 
 func runTest(t *testing.T, name string, dr *backend.DataResponse) *backend.DataResponse {
@@ -0,0 +1,249 @@
+package twinmaker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iottwinmaker"
+	"github.com/grafana/grafana-iot-twinmaker-app/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const qualityGood = "GOOD"
+
+type qualitySample struct {
+	time    time.Time
+	quality *string
+}
+
+// qualityIndex holds the quality samples for each property reference, sorted by time
+type qualityIndex struct {
+	cfg     *models.TwinMakerQuality
+	hidden  bool // the quality property was only fetched for alignment
+	samples map[string][]qualitySample
+}
+
+func validateQuality(q *models.TwinMakerQuality) error {
+	if q == nil {
+		return nil
+	}
+	if q.Property == "" || q.QualityProperty == "" {
+		return fmt.Errorf("quality requires a value property and a quality property")
+	}
+	if q.Mode != "" && q.Mode != models.QualityModeFlag && q.Mode != models.QualityModeFilter {
+		return fmt.Errorf("unknown quality mode: %s", q.Mode)
+	}
+	if q.ToleranceMs < 0 {
+		return fmt.Errorf("quality tolerance must not be negative")
+	}
+	return nil
+}
+
+func hasProperty(properties []*string, name string) bool {
+	for _, p := range properties {
+		if p != nil && *p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// withQualityProperty makes sure both properties of the quality pair are requested
+func withQualityProperty(query models.TwinMakerQuery) models.TwinMakerQuery {
+	q := query.Quality
+	if q == nil {
+		return query
+	}
+	properties := append([]*string{}, query.Properties...)
+	for _, name := range []string{q.Property, q.QualityProperty} {
+		if !hasProperty(properties, name) {
+			properties = append(properties, aws.String(name))
+		}
+	}
+	query.Properties = properties
+	return query
+}
+
+// getHistoryForQuality fetches the history with both properties of the quality pair.  A
+// quality sample can come back on a later page than its value, so with a quality config
+// every page is followed before the values are aligned
+func (s *twinMakerHandler) getHistoryForQuality(ctx context.Context, query models.TwinMakerQuery) (*iottwinmaker.GetPropertyValueHistoryOutput, error) {
+	query = withQualityProperty(query)
+	results, err := s.client.GetPropertyValueHistory(ctx, query)
+	if err != nil || query.Quality == nil {
+		return results, err
+	}
+
+	merged := map[string]*iottwinmaker.PropertyValueHistory{}
+	for _, prop := range results.PropertyValues {
+		merged[historyKey(prop.EntityPropertyReference)] = prop
+	}
+
+	tokens := newPageTokens(ctx, "quality history", query.NextToken)
+	for tokens.follow(results.NextToken) {
+		query.NextToken = *results.NextToken
+
+		page, err := s.client.GetPropertyValueHistory(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, prop := range page.PropertyValues {
+			key := historyKey(prop.EntityPropertyReference)
+			if existing, ok := merged[key]; ok {
+				existing.Values = append(existing.Values, prop.Values...)
+				continue
+			}
+			merged[key] = prop
+			results.PropertyValues = append(results.PropertyValues, prop)
+		}
+		results.NextToken = page.NextToken
+	}
+	results.NextToken = nil // every page was followed
+
+	return results, nil
+}
+
+// historyKey identifies one property of one owner
+func historyKey(ref *iottwinmaker.EntityPropertyReference) string {
+	return referenceKey(ref) + "#" + propertyName(ref)
+}
+
+// referenceKey identifies the owner of a property so values and quality can be paired
+func referenceKey(ref *iottwinmaker.EntityPropertyReference) string {
+	if ref == nil {
+		return ""
+	}
+	key := aws.StringValue(ref.EntityId) + "/" + aws.StringValue(ref.ComponentName)
+	ids := make([]string, 0, len(ref.ExternalIdProperty))
+	for k, v := range ref.ExternalIdProperty {
+		if k == "propertyName" {
+			continue
+		}
+		ids = append(ids, k+"="+aws.StringValue(v))
+	}
+	sort.Strings(ids)
+	return key + "/" + strings.Join(ids, ",")
+}
+
+func propertyName(ref *iottwinmaker.EntityPropertyReference) string {
+	if ref == nil {
+		return ""
+	}
+	return aws.StringValue(ref.PropertyName)
+}
+
+// newQualityIndex collects the quality samples from the results, query is the
+// original query (before withQualityProperty) and may have no quality config
+func newQualityIndex(results []*iottwinmaker.PropertyValueHistory, query models.TwinMakerQuery) *qualityIndex {
+	if query.Quality == nil {
+		return nil
+	}
+	idx := &qualityIndex{
+		cfg:     query.Quality,
+		hidden:  !hasProperty(query.Properties, query.Quality.QualityProperty),
+		samples: map[string][]qualitySample{},
+	}
+	for _, prop := range results {
+		if propertyName(prop.EntityPropertyReference) != idx.cfg.QualityProperty {
+			continue
+		}
+		key := referenceKey(prop.EntityPropertyReference)
+		for _, v := range prop.Values {
+			if v.Timestamp == nil || v.Value == nil {
+				continue
+			}
+			idx.samples[key] = append(idx.samples[key], qualitySample{time: *v.Timestamp, quality: v.Value.StringValue})
+		}
+	}
+	for _, samples := range idx.samples {
+		sort.SliceStable(samples, func(i, j int) bool {
+			return samples[i].time.Before(samples[j].time)
+		})
+	}
+	return idx
+}
+
+// isHidden is true for quality frames that were not requested by the query
+func (idx *qualityIndex) isHidden(ref *iottwinmaker.EntityPropertyReference) bool {
+	return idx != nil && idx.hidden && propertyName(ref) == idx.cfg.QualityProperty
+}
+
+func (idx *qualityIndex) appliesTo(ref *iottwinmaker.EntityPropertyReference) bool {
+	return idx != nil && propertyName(ref) == idx.cfg.Property
+}
+
+// lookup finds the quality sample nearest to t within the tolerance
+func (idx *qualityIndex) lookup(key string, t *time.Time) *string {
+	samples := idx.samples[key]
+	if t == nil || len(samples) == 0 {
+		return nil
+	}
+	i := sort.Search(len(samples), func(i int) bool {
+		return !samples[i].time.Before(*t)
+	})
+
+	var best *qualitySample
+	distance := idx.cfg.Tolerance()
+	for _, j := range []int{i - 1, i} {
+		if j < 0 || j >= len(samples) {
+			continue
+		}
+		d := samples[j].time.Sub(*t)
+		if d < 0 {
+			d = -d
+		}
+		if d < distance || (best == nil && d == distance) { // ties go to the earlier sample
+			best = &samples[j]
+			distance = d
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return best.quality
+}
+
+// align pairs each value with its quality.  In filter mode samples that are not
+// GOOD are dropped and counted separately from the samples with no matching quality,
+// otherwise all values are returned with the quality flags
+func (idx *qualityIndex) align(ref *iottwinmaker.EntityPropertyReference, values []*iottwinmaker.PropertyValue) ([]*iottwinmaker.PropertyValue, []*string, int, int) {
+	key := referenceKey(ref)
+	if idx.cfg.Mode == models.QualityModeFilter {
+		kept := make([]*iottwinmaker.PropertyValue, 0, len(values))
+		unmatched := 0
+		for _, v := range values {
+			q := idx.lookup(key, v.Timestamp)
+			if q == nil {
+				unmatched++
+			} else if strings.EqualFold(*q, qualityGood) {
+				kept = append(kept, v)
+			}
+		}
+		return kept, nil, len(values) - len(kept) - unmatched, unmatched
+	}
+
+	flags := make([]*string, len(values))
+	for i, v := range values {
+		flags[i] = idx.lookup(key, v.Timestamp)
+	}
+	return values, flags, 0, 0
+}
+
+func droppedQualityNotice(count int) data.Notice {
+	return data.Notice{
+		Severity: data.NoticeSeverityInfo,
+		Text:     fmt.Sprintf("%d samples dropped with non-GOOD quality", count),
+	}
+}
+
+func unmatchedQualityNotice(count int) data.Notice {
+	return data.Notice{
+		Severity: data.NoticeSeverityInfo,
+		Text:     fmt.Sprintf("%d samples dropped with no matching quality sample", count),
+	}
+}
@@ -0,0 +1,173 @@
+package twinmaker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iottwinmaker"
+	"github.com/grafana/grafana-iot-twinmaker-app/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func qualityHistory(start time.Time) *iottwinmaker.GetPropertyValueHistoryOutput {
+	ref := func(name string) *iottwinmaker.EntityPropertyReference {
+		return &iottwinmaker.EntityPropertyReference{
+			EntityId:      aws.String("Mixer_1"),
+			ComponentName: aws.String("Telemetry"),
+			PropertyName:  aws.String(name),
+		}
+	}
+	at := func(ms int) *time.Time {
+		return aws.Time(start.Add(time.Duration(ms) * time.Millisecond))
+	}
+
+	return &iottwinmaker.GetPropertyValueHistoryOutput{
+		PropertyValues: []*iottwinmaker.PropertyValueHistory{
+			{
+				EntityPropertyReference: ref("temperature"),
+				Values: []*iottwinmaker.PropertyValue{
+					{Timestamp: at(0), Value: &iottwinmaker.DataValue{DoubleValue: aws.Float64(1)}},
+					{Timestamp: at(1000), Value: &iottwinmaker.DataValue{DoubleValue: aws.Float64(2)}},
+					{Timestamp: at(2000), Value: &iottwinmaker.DataValue{DoubleValue: aws.Float64(3)}},
+					{Timestamp: at(3000), Value: &iottwinmaker.DataValue{DoubleValue: aws.Float64(4)}},
+				},
+			},
+			{
+				EntityPropertyReference: ref("temperature_quality"),
+				Values: []*iottwinmaker.PropertyValue{
+					{Timestamp: at(0), Value: &iottwinmaker.DataValue{StringValue: aws.String("GOOD")}},
+					{Timestamp: at(1040), Value: &iottwinmaker.DataValue{StringValue: aws.String("BAD")}},
+					{Timestamp: at(1980), Value: &iottwinmaker.DataValue{StringValue: aws.String("GOOD")}},
+					{Timestamp: at(2020), Value: &iottwinmaker.DataValue{StringValue: aws.String("UNCERTAIN")}},
+					{Timestamp: at(3500), Value: &iottwinmaker.DataValue{StringValue: aws.String("GOOD")}},
+				},
+			},
+		},
+	}
+}
+
+func stringValues(f *data.Field) []string {
+	vals := make([]string, f.Len())
+	for i := range vals {
+		if v := f.At(i).(*string); v != nil {
+			vals[i] = *v
+		}
+	}
+	return vals
+}
+
+func TestQualityFlags(t *testing.T) {
+	start := time.Date(2021, 11, 5, 0, 0, 0, 0, time.UTC)
+	handler := &twinMakerHandler{}
+	query := func(mode models.TwinMakerQualityMode, tolerance int64) models.TwinMakerQuery {
+		return models.TwinMakerQuery{
+			EntityId:      "Mixer_1",
+			ComponentName: "Telemetry",
+			Properties:    []*string{aws.String("temperature")},
+			Quality: &models.TwinMakerQuality{
+				Property:        "temperature",
+				QualityProperty: "temperature_quality",
+				Mode:            mode,
+				ToleranceMs:     tolerance,
+			},
+		}
+	}
+
+	t.Run("quality property is fetched with the values", func(t *testing.T) {
+		q := withQualityProperty(query(models.QualityModeFlag, 0))
+		require.Equal(t, []*string{aws.String("temperature"), aws.String("temperature_quality")}, q.Properties)
+	})
+
+	t.Run("flag adds a quality field", func(t *testing.T) {
+		dr := handler.processHistory(qualityHistory(start), nil, query(models.QualityModeFlag, 50), nil)
+		require.NoError(t, dr.Error)
+		require.Len(t, dr.Frames, 1) // quality frame was not requested
+
+		frame := dr.Frames[0]
+		require.Len(t, frame.Fields, 3)
+		require.Equal(t, 4, frame.Rows())
+		require.Equal(t, "quality", frame.Fields[2].Name)
+		require.Equal(t, frame.Fields[1].Labels, frame.Fields[2].Labels)
+		// 2000 is 20ms from both GOOD and UNCERTAIN, the earlier wins; 3000 has no match
+		require.Equal(t, []string{"GOOD", "BAD", "GOOD", ""}, stringValues(frame.Fields[2]))
+		require.Empty(t, frame.Meta.Notices)
+	})
+
+	t.Run("filter drops samples that are not GOOD", func(t *testing.T) {
+		dr := handler.processHistory(qualityHistory(start), nil, query(models.QualityModeFilter, 50), nil)
+		require.NoError(t, dr.Error)
+		require.Len(t, dr.Frames, 1)
+
+		frame := dr.Frames[0]
+		require.Len(t, frame.Fields, 2)
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, 1.0, *(frame.Fields[1].At(0).(*float64)))
+		require.Equal(t, 3.0, *(frame.Fields[1].At(1).(*float64)))
+		// 1000 is BAD, 3000 has no quality within the tolerance
+		require.Equal(t, []data.Notice{droppedQualityNotice(1), unmatchedQualityNotice(1)}, frame.Meta.Notices)
+	})
+
+	t.Run("quality series shorter than the values", func(t *testing.T) {
+		results := qualityHistory(start)
+		results.PropertyValues[1].Values = results.PropertyValues[1].Values[:1] // only GOOD at 0
+
+		dr := handler.processHistory(results, nil, query(models.QualityModeFlag, 50), nil)
+		require.NoError(t, dr.Error)
+		require.Equal(t, []string{"GOOD", "", "", ""}, stringValues(dr.Frames[0].Fields[2]))
+
+		dr = handler.processHistory(results, nil, query(models.QualityModeFilter, 50), nil)
+		require.NoError(t, dr.Error)
+		require.Equal(t, 1, dr.Frames[0].Rows())
+		require.Equal(t, []data.Notice{unmatchedQualityNotice(3)}, dr.Frames[0].Meta.Notices)
+	})
+
+	t.Run("tolerance bounds the nearest match", func(t *testing.T) {
+		dr := handler.processHistory(qualityHistory(start), nil, query(models.QualityModeFlag, 0), nil)
+		require.NoError(t, dr.Error)
+		require.Equal(t, []string{"GOOD", "", "", ""}, stringValues(dr.Frames[0].Fields[2]))
+
+		dr = handler.processHistory(qualityHistory(start), nil, query(models.QualityModeFlag, 500), nil)
+		require.NoError(t, dr.Error)
+		require.Equal(t, []string{"GOOD", "BAD", "GOOD", "GOOD"}, stringValues(dr.Frames[0].Fields[2]))
+	})
+
+	t.Run("requested quality property is kept", func(t *testing.T) {
+		q := query(models.QualityModeFlag, 50)
+		q.Properties = append(q.Properties, aws.String("temperature_quality"))
+		dr := handler.processHistory(qualityHistory(start), nil, q, nil)
+		require.NoError(t, dr.Error)
+		require.Len(t, dr.Frames, 2)
+	})
+
+	t.Run("quality split across history pages", func(t *testing.T) {
+		// the quality for the value at 1000 comes back on the second page
+		client := &twinMakerMockClient{
+			responses: []string{"quality-history-page-1", "quality-history-page-2"},
+		}
+		q := query(models.QualityModeFilter, 50)
+		q.TimeRange = backend.TimeRange{From: start, To: start.Add(time.Hour)}
+		dr := NewTwinMakerHandler(client).GetEntityHistory(context.Background(), q)
+		require.NoError(t, dr.Error)
+		require.Len(t, client.historyQueries, 2)
+		require.Equal(t, "page-2", client.historyQueries[1].NextToken)
+
+		frame := dr.Frames[0]
+		require.Len(t, dr.Frames, 1)
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, []data.Notice{droppedQualityNotice(1)}, frame.Meta.Notices)
+		require.Equal(t, models.TwinMakerCustomMeta{}, frame.Meta.Custom) // every page was followed
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		require.Error(t, validateQuality(&models.TwinMakerQuality{Property: "temperature"}))
+		q := query("OTHER", 0)
+		require.Error(t, validateQuality(q.Quality))
+		q = query(models.QualityModeFilter, -1)
+		require.Error(t, validateQuality(q.Quality))
+		require.NoError(t, validateQuality(nil))
+	})
+}
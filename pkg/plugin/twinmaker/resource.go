@@ -86,7 +86,8 @@ func (r *twinMakerResource) ListScenes(ctx context.Context) ([]models.Selectable
 		if err != nil {
 			return results, err
 		}
-		for _, w := range rsp.SceneSummaries {
+		scenes, _ := dedupeSceneSummaries(rsp.SceneSummaries)
+		for _, w := range scenes {
 			info := models.SelectableString{}
 			if w.SceneId != nil {
 				info.Label = *w.SceneId
@@ -123,7 +124,8 @@ func (r *twinMakerResource) ListOptions(ctx context.Context) (models.OptionsInfo
 			return results, err
 		}
 
-		for _, w := range rsp.EntitySummaries {
+		entities, _ := dedupeEntitySummaries(rsp.EntitySummaries)
+		for _, w := range entities {
 			info := models.SelectableString{}
 			if w.EntityId != nil {
 				info.Label = *w.EntityId
@@ -155,7 +157,8 @@ func (r *twinMakerResource) ListOptions(ctx context.Context) (models.OptionsInfo
 			return results, err
 		}
 
-		for _, w := range rsp.ComponentTypeSummaries {
+		componentTypes, _ := dedupeComponentTypeSummaries(rsp.ComponentTypeSummaries)
+		for _, w := range componentTypes {
 			if w.ComponentTypeId == nil {
 				continue
 			}
@@ -12,12 +12,22 @@ import (
 type cachingResource struct {
 	res   TwinMakerResources
 	stash *cache.Cache
+	ttl   time.Duration
 }
 
 func NewCachingResource(res TwinMakerResources, ttl time.Duration) TwinMakerResources {
 	return &cachingResource{
 		res:   res,
 		stash: cache.New(ttl, ttl*2),
+		ttl:   ttl,
+	}
+}
+
+func (s *cachingResource) CacheStats() CacheStats {
+	return CacheStats{
+		Name:  "resource",
+		Items: s.stash.ItemCount(),
+		TTL:   s.ttl.String(),
 	}
 }
 
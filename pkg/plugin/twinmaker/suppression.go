@@ -0,0 +1,186 @@
+package twinmaker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iottwinmaker"
+	"github.com/grafana/grafana-iot-twinmaker-app/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	alarmStatusProperty   = "alarm_status"
+	alarmStatusActive     = "ACTIVE"
+	alarmStatusSuppressed = "SUPPRESSED"
+)
+
+// maintenanceWindows are the suppression windows clipped to the query time range
+type maintenanceWindows []models.TwinMakerTimeWindow
+
+func (w maintenanceWindows) contains(t *time.Time) bool {
+	if t == nil {
+		return false
+	}
+	for _, window := range w {
+		if !t.Before(window.From) && !t.After(window.To) {
+			return true
+		}
+	}
+	return false
+}
+
+// suppress returns SUPPRESSED for ACTIVE values that fall within a window
+func (w maintenanceWindows) suppress(status *string, t *time.Time) (*string, bool) {
+	if len(w) == 0 || status == nil || *status != alarmStatusActive || !w.contains(t) {
+		return status, false
+	}
+	return aws.String(alarmStatusSuppressed), true
+}
+
+func clipWindow(window models.TwinMakerTimeWindow, tr backend.TimeRange) (models.TwinMakerTimeWindow, bool) {
+	if window.To.IsZero() || window.To.After(tr.To) {
+		window.To = tr.To
+	}
+	if window.From.Before(tr.From) {
+		window.From = tr.From
+	}
+	return window, !window.To.Before(window.From)
+}
+
+// maintenanceLookback is how far before the range the last maintenance state is searched for
+const maintenanceLookback = 7 * 24 * time.Hour
+
+// windowsFromHistory converts a boolean maintenance property into windows.  Each
+// true value opens a window that is closed by the next false value (or the end
+// of the range).  When the property was already true at the start of the range,
+// the first window opens at tr.From.
+func windowsFromHistory(values []*iottwinmaker.PropertyValue, tr backend.TimeRange, inMaintenance bool) []models.TwinMakerTimeWindow {
+	sorted := make([]*iottwinmaker.PropertyValue, 0, len(values))
+	for _, v := range values {
+		if v.Timestamp != nil && v.Value != nil && v.Value.BooleanValue != nil {
+			sorted = append(sorted, v)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(*sorted[j].Timestamp)
+	})
+
+	windows := []models.TwinMakerTimeWindow{}
+	var start *time.Time
+	if inMaintenance {
+		start = &tr.From
+	}
+	for _, v := range sorted {
+		inMaintenance := *v.Value.BooleanValue
+		if inMaintenance && start == nil {
+			start = v.Timestamp
+		} else if !inMaintenance && start != nil {
+			windows = append(windows, models.TwinMakerTimeWindow{From: *start, To: *v.Timestamp})
+			start = nil
+		}
+	}
+	if start != nil {
+		windows = append(windows, models.TwinMakerTimeWindow{From: *start, To: tr.To})
+	}
+	return windows
+}
+
+// getMaintenanceWindows resolves the query suppression config into windows.  It returns
+// nil when suppression is disabled so the raw values are shown.
+func (s *twinMakerHandler) getMaintenanceWindows(ctx context.Context, query models.TwinMakerQuery) (maintenanceWindows, error) {
+	cfg := query.Suppression
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+
+	windows := append([]models.TwinMakerTimeWindow{}, cfg.Windows...)
+	if cfg.PropertyName != "" {
+		if cfg.EntityId == "" || cfg.ComponentName == "" {
+			return nil, fmt.Errorf("maintenance property requires an entity and component")
+		}
+
+		propertyQuery := models.TwinMakerQuery{
+			WorkspaceId:   query.WorkspaceId,
+			EntityId:      cfg.EntityId,
+			ComponentName: cfg.ComponentName,
+			Properties:    []*string{aws.String(cfg.PropertyName)},
+		}
+
+		inMaintenance, err := s.getMaintenanceState(ctx, propertyQuery, query.TimeRange.From)
+		if err != nil {
+			return nil, fmt.Errorf("could not load maintenance windows: %w", err)
+		}
+
+		values, err := s.getMaintenanceHistory(ctx, propertyQuery, query.TimeRange)
+		if err != nil {
+			return nil, fmt.Errorf("could not load maintenance windows: %w", err)
+		}
+		windows = append(windows, windowsFromHistory(values, query.TimeRange, inMaintenance)...)
+	}
+
+	clipped := make(maintenanceWindows, 0, len(windows))
+	for _, w := range windows {
+		if c, ok := clipWindow(w, query.TimeRange); ok {
+			clipped = append(clipped, c)
+		}
+	}
+	return clipped, nil
+}
+
+// getMaintenanceState returns the last value of the maintenance property at or before t
+func (s *twinMakerHandler) getMaintenanceState(ctx context.Context, query models.TwinMakerQuery, t time.Time) (bool, error) {
+	query.Order = models.ResultOrderDesc
+	query.MaxResults = 1
+	query.TimeRange = backend.TimeRange{
+		From: t.Add(-maintenanceLookback),
+		To:   t,
+	}
+
+	results, err := s.client.GetPropertyValueHistory(ctx, query)
+	if err != nil {
+		return false, err
+	}
+	for _, prop := range results.PropertyValues {
+		for _, v := range prop.Values {
+			if v.Value != nil && v.Value.BooleanValue != nil {
+				return *v.Value.BooleanValue, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// getMaintenanceHistory returns every value of the maintenance property within the range
+func (s *twinMakerHandler) getMaintenanceHistory(ctx context.Context, query models.TwinMakerQuery, tr backend.TimeRange) ([]*iottwinmaker.PropertyValue, error) {
+	query.Order = models.ResultOrderAsc
+	query.TimeRange = tr
+
+	values := []*iottwinmaker.PropertyValue{}
+	tokens := newPageTokens(ctx, "maintenance history", "")
+	for {
+		results, err := s.client.GetPropertyValueHistory(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, prop := range results.PropertyValues {
+			values = append(values, prop.Values...)
+		}
+
+		if !tokens.follow(results.NextToken) {
+			return values, nil
+		}
+		query.NextToken = *results.NextToken
+	}
+}
+
+func suppressedNotice(count int) data.Notice {
+	return data.Notice{
+		Severity: data.NoticeSeverityInfo,
+		Text:     fmt.Sprintf("%d ACTIVE alarm values suppressed during maintenance", count),
+	}
+}
@@ -0,0 +1,176 @@
+package twinmaker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iottwinmaker"
+	"github.com/grafana/grafana-iot-twinmaker-app/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func alarmHistory(start time.Time, statuses ...string) *iottwinmaker.GetPropertyValueHistoryOutput {
+	values := make([]*iottwinmaker.PropertyValue, len(statuses))
+	for i, status := range statuses {
+		values[i] = &iottwinmaker.PropertyValue{
+			Timestamp: aws.Time(start.Add(time.Duration(i) * time.Hour)),
+			Value:     &iottwinmaker.DataValue{StringValue: aws.String(status)},
+		}
+	}
+	return &iottwinmaker.GetPropertyValueHistoryOutput{
+		PropertyValues: []*iottwinmaker.PropertyValueHistory{
+			{
+				EntityPropertyReference: &iottwinmaker.EntityPropertyReference{
+					EntityId:      aws.String("Mixer_1"),
+					ComponentName: aws.String("AlarmComponent"),
+					PropertyName:  aws.String(alarmStatusProperty),
+				},
+				Values: values,
+			},
+		},
+	}
+}
+
+func TestMaintenanceSuppression(t *testing.T) {
+	start := time.Date(2021, 11, 5, 0, 0, 0, 0, time.UTC)
+	tr := backend.TimeRange{
+		From: start,
+		To:   start.Add(5 * time.Hour),
+	}
+	client, err := NewTwinMakerMockClient("x")
+	require.NoError(t, err)
+	handler := &twinMakerHandler{client: client}
+
+	query := models.TwinMakerQuery{
+		TimeRange: tr,
+		Suppression: &models.TwinMakerSuppression{
+			Enabled: true,
+			Windows: []models.TwinMakerTimeWindow{
+				// starts before the range and ends at the second value
+				{From: start.Add(-2 * time.Hour), To: start.Add(1 * time.Hour)},
+				// open ended, starts within the range
+				{From: start.Add(4 * time.Hour)},
+				// entirely outside the range
+				{From: start.Add(10 * time.Hour), To: start.Add(12 * time.Hour)},
+			},
+		},
+	}
+
+	t.Run("windows are clipped to the query range", func(t *testing.T) {
+		windows, err := handler.getMaintenanceWindows(context.Background(), query)
+		require.NoError(t, err)
+		require.Equal(t, maintenanceWindows{
+			{From: tr.From, To: start.Add(1 * time.Hour)},
+			{From: start.Add(4 * time.Hour), To: tr.To},
+		}, windows)
+	})
+
+	t.Run("ACTIVE values within windows are suppressed", func(t *testing.T) {
+		windows, err := handler.getMaintenanceWindows(context.Background(), query)
+		require.NoError(t, err)
+
+		results := alarmHistory(start, "ACTIVE", "ACTIVE", "ACTIVE", "NORMAL", "ACTIVE", "NORMAL")
+		dr := handler.processHistory(results, nil, query, windows)
+		require.NoError(t, dr.Error)
+		require.Len(t, dr.Frames, 1)
+
+		v := dr.Frames[0].Fields[1]
+		statuses := make([]string, v.Len())
+		for i := range statuses {
+			statuses[i] = *(v.At(i).(*string))
+		}
+		require.Equal(t, []string{"SUPPRESSED", "SUPPRESSED", "ACTIVE", "NORMAL", "SUPPRESSED", "NORMAL"}, statuses)
+		require.Equal(t, []data.Notice{suppressedNotice(3)}, dr.Frames[0].Meta.Notices)
+
+		// the raw values are left untouched
+		require.Equal(t, "ACTIVE", *results.PropertyValues[0].Values[0].Value.StringValue)
+	})
+
+	t.Run("disabled suppression returns the raw values", func(t *testing.T) {
+		disabled := query
+		disabled.Suppression = &models.TwinMakerSuppression{Windows: query.Suppression.Windows}
+		windows, err := handler.getMaintenanceWindows(context.Background(), disabled)
+		require.NoError(t, err)
+		require.Nil(t, windows)
+
+		dr := handler.processHistory(alarmHistory(start, "ACTIVE", "ACTIVE"), nil, disabled, windows)
+		require.NoError(t, dr.Error)
+		require.Equal(t, "ACTIVE", *(dr.Frames[0].Fields[1].At(0).(*string)))
+		require.Empty(t, dr.Frames[0].Meta.Notices)
+	})
+
+	t.Run("maintenance property requires entity and component", func(t *testing.T) {
+		invalid := query
+		invalid.Suppression = &models.TwinMakerSuppression{Enabled: true, PropertyName: "inMaintenance"}
+		_, err := handler.getMaintenanceWindows(context.Background(), invalid)
+		require.Error(t, err)
+	})
+
+	t.Run("maintenance property is loaded from history", func(t *testing.T) {
+		client := &twinMakerMockClient{
+			responses: []string{"maintenance-state", "maintenance-history-page-1", "maintenance-history-page-2"},
+		}
+		handler := &twinMakerHandler{client: client}
+
+		fromProperty := query
+		fromProperty.Suppression = &models.TwinMakerSuppression{
+			Enabled:       true,
+			EntityId:      "Mixer_1_4b57cbee-c391-4de6-b882-622c633a697e",
+			ComponentName: "MaintenanceComponent",
+			PropertyName:  "inMaintenance",
+		}
+		windows, err := handler.getMaintenanceWindows(context.Background(), fromProperty)
+		require.NoError(t, err)
+
+		// already in maintenance at the start of the range, the second page opens a new window
+		require.Equal(t, maintenanceWindows{
+			{From: tr.From, To: start.Add(1 * time.Hour)},
+			{From: start.Add(3 * time.Hour), To: tr.To},
+		}, windows)
+
+		require.Len(t, client.historyQueries, 3)
+		state := client.historyQueries[0]
+		require.Equal(t, models.ResultOrderDesc, state.Order)
+		require.Equal(t, int64(1), state.MaxResults)
+		require.Equal(t, tr.From, state.TimeRange.To)
+		require.Equal(t, "", client.historyQueries[1].NextToken)
+		require.Equal(t, "page-2", client.historyQueries[2].NextToken)
+		require.Equal(t, tr, client.historyQueries[2].TimeRange)
+	})
+}
+
+func TestWindowsFromHistory(t *testing.T) {
+	start := time.Date(2021, 11, 5, 0, 0, 0, 0, time.UTC)
+	tr := backend.TimeRange{From: start, To: start.Add(10 * time.Hour)}
+	value := func(hour int, v bool) *iottwinmaker.PropertyValue {
+		return &iottwinmaker.PropertyValue{
+			Timestamp: aws.Time(start.Add(time.Duration(hour) * time.Hour)),
+			Value:     &iottwinmaker.DataValue{BooleanValue: aws.Bool(v)},
+		}
+	}
+
+	windows := windowsFromHistory([]*iottwinmaker.PropertyValue{
+		value(8, true), // out of order, still open at the end of the range
+		value(1, true),
+		value(2, true),
+		value(3, false),
+		value(5, false),
+	}, tr, false)
+	require.Equal(t, []models.TwinMakerTimeWindow{
+		{From: start.Add(1 * time.Hour), To: start.Add(3 * time.Hour)},
+		{From: start.Add(8 * time.Hour), To: tr.To},
+	}, windows)
+
+	windows = windowsFromHistory([]*iottwinmaker.PropertyValue{
+		value(2, false),
+		value(4, true),
+	}, tr, true)
+	require.Equal(t, []models.TwinMakerTimeWindow{
+		{From: tr.From, To: start.Add(2 * time.Hour)},
+		{From: start.Add(4 * time.Hour), To: tr.To},
+	}, windows)
+}
@@ -34,6 +34,33 @@ export interface TwinMakerPropertyFilter {
   op: string;
 }
 
+export interface TwinMakerTimeWindow {
+  from: string; // ISO-8601
+  to?: string; // open ended when missing
+}
+
+export interface TwinMakerSuppression {
+  enabled?: boolean;
+  windows?: TwinMakerTimeWindow[];
+
+  // maintenance component consulted at query time
+  entityId?: string;
+  componentName?: string;
+  propertyName?: string;
+}
+
+export enum TwinMakerQualityMode {
+  FLAG = 'FLAG',
+  FILTER = 'FILTER',
+}
+
+export interface TwinMakerQuality {
+  property: string;
+  qualityProperty: string;
+  mode?: TwinMakerQualityMode;
+  toleranceMs?: number;
+}
+
 export interface TwinMakerQuery extends DataQuery {
   queryType?: TwinMakerQueryType;
   nextToken?: string;
@@ -45,6 +72,8 @@ export interface TwinMakerQuery extends DataQuery {
   properties?: string[];
   filter?: TwinMakerPropertyFilter[];
   order?: TwinMakerResultOrder;
+  suppression?: TwinMakerSuppression;
+  quality?: TwinMakerQuality;
 }
 
 export interface TwinMakerPanelQuery extends TwinMakerQuery {
@@ -16,6 +16,8 @@ export interface AWSTokenInfo {
  */
 export interface TwinMakerCustomMeta {
   nextToken?: string;
+  duplicatesDropped?: number;
+  paginationRestarts?: number;
 }
 
 /**
@@ -23,6 +25,13 @@ export interface TwinMakerCustomMeta {
  */
 export interface TwinMakerDataSourceOptions extends AwsAuthDataSourceJsonData {
   workspaceId?: string;
+
+  // Added by the backend settings migration when missing
+  settingsVersion?: number;
+  sessionDuration?: string; // ie "1h"
+  cacheTTL?: string; // ie "30m"
+  stsEndpoint?: string;
+  allowWrites?: boolean;
 }
 export interface TwinMakerSecureJsonData extends AwsAuthDataSourceSecureJsonData {
   // nothing for now